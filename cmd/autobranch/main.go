@@ -9,6 +9,7 @@ import (
     "strings"
     "time"
 
+    "github.com/amanmehtacode/GitNoob/internal/journal"
     "github.com/briandowns/spinner"
     "github.com/fatih/color"
     "github.com/spf13/cobra"
@@ -50,6 +51,15 @@ func autoCommit(cmd *cobra.Command, args []string) {
     // Get commit message from user
     commitMessage := getCommitMessage()
 
+    // Record the state before committing so rollbackhelper can undo it
+    entry, err := journal.Begin("autobranch", "commit: "+commitMessage)
+    if err != nil {
+        logError("Could not record operation for rollbackhelper", err)
+    } else {
+        entry.UndoMode = journal.UndoSoft
+    }
+    defer entry.Finish()
+
     // Stage and commit changes
     fmt.Println(yellow("→ Staging and committing changes..."))
     if err := commitChanges(commitMessage); err != nil {
@@ -144,7 +154,7 @@ func stopSpinner() {
 
 func printFormattedOutput(output string) {
     lines := strings.Split(strings.TrimSpace(output), "\n")
-    for _, line := lines {
+    for _, line := range lines {
         if strings.HasPrefix(line, "[") {
             fmt.Println(green(line))
         } else if strings.Contains(line, "|") {
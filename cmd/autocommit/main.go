@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/amanmehtacode/GitNoob/internal/journal"
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -48,6 +49,10 @@ func autoCommit(cmd *cobra.Command, args []string) {
 
 	commitMessage := getCommitMessage()
 
+	// Record the state before committing so rollbackhelper can undo it
+	entry := beginJournal(commitMessage)
+	defer entry.Finish()
+
 	if err := commitChanges(commitMessage); err != nil {
 		logError("Error committing changes", err)
 		return
@@ -63,6 +68,17 @@ func autoCommit(cmd *cobra.Command, args []string) {
 	fmt.Println(green("✓ Changes have been committed and pushed successfully! 🚀"))
 }
 
+// beginJournal records an autocommit operation, warning if the journal cannot be written
+func beginJournal(commitMessage string) *journal.Entry {
+	entry, err := journal.Begin("autocommit", "commit: "+commitMessage)
+	if err != nil {
+		logError("Could not record operation for rollbackhelper", err)
+		return nil
+	}
+	entry.UndoMode = journal.UndoSoft
+	return entry
+}
+
 func commitChanges(commitMessage string) error {
 	startSpinner("Committing changes")
 	defer stopSpinner()
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/amanmehtacode/GitNoob/internal/journal"
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -40,6 +41,13 @@ func autoMerge(cmd *cobra.Command, args []string) {
 		log.Fatalf("Failed to get branches: %v", err)
 	}
 
+	// Record the state before merging so rollbackhelper can undo it
+	entry, err := journal.Begin("automerge", "merge all branches into main")
+	if err != nil {
+		logError("Could not record operation for rollbackhelper", err)
+	}
+	defer entry.Finish()
+
 	if err := checkoutBranch("main"); err != nil {
 		log.Fatalf("Failed to checkout main branch: %v", err)
 	}
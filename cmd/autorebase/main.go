@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Onto          string
+	DefaultBranch bool
+	Autosquash    bool
+	Autostash     bool
+	NoFetch       bool
+	Continue      bool
+	Abort         bool
+	Skip          bool
+	VerboseMode   bool
+}
+
+// Global variables
+var (
+	cfg Config
+	s   *spinner.Spinner
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "autorebase",
+		Short: "Rebase the current branch onto its upstream, the default branch or a chosen base",
+		Run:   autoRebase,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().StringVar(&cfg.Onto, "onto", "", "Rebase onto this ref instead of the upstream")
+	rootCmd.Flags().BoolVarP(&cfg.DefaultBranch, "default-branch", "d", false, "Rebase onto the remote default branch (e.g. origin/main)")
+	rootCmd.Flags().BoolVar(&cfg.Autosquash, "autosquash", false, "Squash fixup!/squash! commits while rebasing")
+	rootCmd.Flags().BoolVar(&cfg.Autostash, "autostash", false, "Stash uncommitted changes before rebasing and restore them afterwards")
+	rootCmd.Flags().BoolVar(&cfg.NoFetch, "no-fetch", false, "Do not fetch the remote before rebasing")
+	rootCmd.Flags().BoolVar(&cfg.Continue, "continue", false, "Continue a stopped rebase after resolving conflicts")
+	rootCmd.Flags().BoolVar(&cfg.Abort, "abort", false, "Abort the rebase and restore the original branch")
+	rootCmd.Flags().BoolVar(&cfg.Skip, "skip", false, "Skip the commit that stopped the rebase")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.MarkFlagsMutuallyExclusive("continue", "abort", "skip")
+	rootCmd.MarkFlagsMutuallyExclusive("onto", "default-branch")
+	rootCmd.AddCommand(newStackCmd(), newRestackCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func autoRebase(cmd *cobra.Command, args []string) {
+	switch {
+	case cfg.Continue:
+		resumeRebase("--continue")
+		return
+	case cfg.Abort:
+		abortRebase()
+		return
+	case cfg.Skip:
+		resumeRebase("--skip")
+		return
+	}
+
+	if state, ok := readRebaseState(); ok {
+		fmt.Println(yellow("A rebase is already in progress."))
+		printConflictGuidance(state)
+		os.Exit(1)
+	}
+
+	branch := currentBranch()
+	if branch == "" {
+		log.Fatal(red("HEAD is detached. Check out the branch you want to rebase first."))
+	}
+
+	base, remote, err := resolveBase(branch)
+	if err != nil {
+		log.Fatalf(red("Cannot determine what to rebase onto: %v"), err)
+	}
+
+	if remote != "" && !cfg.NoFetch {
+		if err := fetchRemote(remote); err != nil {
+			log.Fatalf(red("Failed to fetch %s: %v"), remote, err)
+		}
+	}
+
+	if !cfg.Autostash && hasUncommittedChanges() {
+		log.Fatal(red("You have uncommitted changes. Commit or stash them, or rerun with --autostash."))
+	}
+
+	count := strings.TrimSpace(gitOutput("rev-list", "--count", base+"..HEAD"))
+	fmt.Println(yellow(fmt.Sprintf("→ Rebasing %s (%s commit(s)) onto %s...", branch, count, base)))
+
+	output, err := runRebase(rebaseArgs(base)...)
+	if err != nil {
+		if state, ok := readRebaseState(); ok {
+			fmt.Println(red("✗ Rebase stopped with conflicts."))
+			printConflictGuidance(state)
+			os.Exit(1)
+		}
+		log.Fatalf(red("Rebase failed: %v\n%s"), err, output)
+	}
+	logVerbose(output)
+
+	fmt.Println(green(fmt.Sprintf("✓ Rebase completed successfully. %s is now up to date with %s.", branch, base)))
+}
+
+// rebaseArgs builds the git rebase invocation for the chosen options
+func rebaseArgs(base string) []string {
+	args := []string{"rebase"}
+	if cfg.Autosquash {
+		// --autosquash only takes effect in interactive mode; accept the todo list as-is
+		args = append(args, "--interactive", "--autosquash")
+	}
+	if cfg.Autostash {
+		args = append(args, "--autostash")
+	}
+	return append(args, base)
+}
+
+// resolveBase picks the ref to rebase onto and the remote it lives on, if any
+func resolveBase(branch string) (string, string, error) {
+	if cfg.Onto != "" {
+		return cfg.Onto, remoteOfRef(cfg.Onto), nil
+	}
+
+	if !cfg.DefaultBranch {
+		remote := gitConfig("branch." + branch + ".remote")
+		merge := strings.TrimPrefix(gitConfig("branch."+branch+".merge"), "refs/heads/")
+		if remote != "" && merge != "" {
+			if remote == "." {
+				return merge, "", nil
+			}
+			return remote + "/" + merge, remote, nil
+		}
+		logVerbose(fmt.Sprintf("%s has no upstream, falling back to the default branch", branch))
+	}
+
+	remote := defaultRemote()
+	base, err := defaultBranch(remote)
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.HasPrefix(base, remote+"/") {
+		// Fell back to a local branch, so there is nothing to fetch
+		remote = ""
+	}
+	return base, remote, nil
+}
+
+// defaultBranch returns the remote default branch, e.g. origin/main
+func defaultBranch(remote string) (string, error) {
+	if ref := strings.TrimSpace(gitOutput("symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")); ref != "" {
+		return ref, nil
+	}
+	for _, name := range []string{"main", "master", "develop"} {
+		if refExists("refs/remotes/" + remote + "/" + name) {
+			return remote + "/" + name, nil
+		}
+	}
+	for _, name := range []string{"main", "master"} {
+		if refExists("refs/heads/" + name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no default branch found on %s; use --onto", remote)
+}
+
+// defaultRemote returns origin, or the only remote when there is just one
+func defaultRemote() string {
+	remotes := strings.Fields(gitOutput("remote"))
+	if len(remotes) == 1 {
+		return remotes[0]
+	}
+	return "origin"
+}
+
+// remoteOfRef returns the remote a ref like origin/main belongs to
+func remoteOfRef(ref string) string {
+	for _, remote := range strings.Fields(gitOutput("remote")) {
+		if strings.HasPrefix(ref, remote+"/") && refExists("refs/remotes/"+ref) {
+			return remote
+		}
+	}
+	return ""
+}
+
+func fetchRemote(remote string) error {
+	startSpinner(fmt.Sprintf("Fetching latest changes from %s", remote))
+	defer stopSpinner()
+	return runCommand("git", "fetch", remote)
+}
+
+// resumeRebase runs git rebase --continue or --skip and reports where the rebase stands
+func resumeRebase(action string) {
+	state, ok := readRebaseState()
+	if !ok {
+		log.Fatal(red("No rebase in progress."))
+	}
+
+	if action == "--continue" {
+		if files := unmergedFiles(); len(files) > 0 {
+			fmt.Println(red("✗ Some files still have unresolved conflicts."))
+			printConflictGuidance(state)
+			os.Exit(1)
+		}
+		if files := filesWithMarkers(); len(files) > 0 {
+			fmt.Println(red("✗ These staged files still contain conflict markers:"))
+			for _, file := range files {
+				fmt.Printf("  %s\n", file)
+			}
+			os.Exit(1)
+		}
+	}
+
+	output, err := runRebase("rebase", action)
+	if next, ok := readRebaseState(); ok {
+		if err == nil {
+			// Stopped for another reason, such as an edit step
+			fmt.Println(yellow(fmt.Sprintf("Rebase paused at commit %d of %d.", next.Current, next.Total)))
+			return
+		}
+		fmt.Println(red("✗ Rebase stopped with conflicts again."))
+		printConflictGuidance(next)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf(red("git rebase %s failed: %v\n%s"), action, err, output)
+	}
+	logVerbose(output)
+	fmt.Println(green(fmt.Sprintf("✓ Rebase of %s completed successfully.", state.Branch)))
+	finishRestack()
+}
+
+func abortRebase() {
+	state, ok := readRebaseState()
+	if !ok {
+		log.Fatal(red("No rebase in progress."))
+	}
+	if err := runCommand("git", "rebase", "--abort"); err != nil {
+		log.Fatalf(red("Failed to abort rebase: %v"), err)
+	}
+	clearRestack()
+	fmt.Println(green(fmt.Sprintf("✓ Rebase aborted. %s is back where it started.", state.Branch)))
+}
+
+// rebaseState is what git records in .git/rebase-merge or .git/rebase-apply
+type rebaseState struct {
+	Branch  string
+	Onto    string
+	Current int
+	Total   int
+	Stopped string
+}
+
+// readRebaseState reads the progress of a stopped rebase
+func readRebaseState() (rebaseState, bool) {
+	var state rebaseState
+	dir := gitPath("rebase-merge")
+	current, total := "msgnum", "end"
+	if !isDir(dir) {
+		dir = gitPath("rebase-apply")
+		current, total = "next", "last"
+		if !isDir(dir) {
+			return state, false
+		}
+	}
+
+	state.Branch = strings.TrimPrefix(readStateFile(dir, "head-name"), "refs/heads/")
+	state.Onto = readStateFile(dir, "onto")
+	state.Current, _ = strconv.Atoi(readStateFile(dir, current))
+	state.Total, _ = strconv.Atoi(readStateFile(dir, total))
+	state.Stopped = readStateFile(dir, "stopped-sha")
+	if state.Stopped == "" {
+		state.Stopped = strings.TrimSpace(gitOutput("rev-parse", "--verify", "--quiet", "REBASE_HEAD"))
+	}
+	return state, true
+}
+
+func readStateFile(dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// conflictHelp explains each porcelain conflict code. During a rebase "us"
+// is the branch being rebased onto and "them" is your commit being replayed.
+var conflictHelp = map[string]string{
+	"UU": "both modified: edit the file, remove the conflict markers, then 'git add %s'",
+	"AA": "both added: merge the two versions by hand, then 'git add %s'",
+	"DU": "deleted upstream, modified by your commit: keep it with 'git add %s' or drop it with 'git rm %s'",
+	"UD": "modified upstream, deleted by your commit: keep it with 'git add %s' or delete it with 'git rm %s'",
+	"AU": "added upstream only: keep it with 'git add %s' or remove it with 'git rm %s'",
+	"UA": "added by your commit only: keep it with 'git add %s' or remove it with 'git rm %s'",
+	"DD": "deleted on both sides: confirm with 'git rm %s'",
+}
+
+// printConflictGuidance shows progress, the commit that stopped and what to do with each file
+func printConflictGuidance(state rebaseState) {
+	if state.Total > 0 {
+		fmt.Printf("%s commit %d of %d", yellow("Progress:"), state.Current, state.Total)
+		if state.Branch != "" {
+			fmt.Printf(" (rebasing %s onto %s)", state.Branch, shortHash(state.Onto))
+		}
+		fmt.Println()
+	}
+	if state.Stopped != "" {
+		fmt.Printf("%s %s\n", yellow("Stopped at:"), strings.TrimSpace(gitOutput("log", "-1", "--format=%h %s (%an)", state.Stopped)))
+	}
+
+	files := unmergedFiles()
+	if len(files) > 0 {
+		fmt.Println(yellow("\nConflicted files:"))
+		for _, file := range files {
+			help, ok := conflictHelp[file.Code]
+			if !ok {
+				help = "resolve the file, then 'git add %s'"
+			}
+			help = strings.ReplaceAll(help, "%s", file.Path)
+			fmt.Printf("  %s %s\n", red(file.Path), help)
+			if markers := countMarkers(file.Path); markers > 0 {
+				fmt.Printf("    %d conflict region(s)\n", markers)
+			}
+		}
+	}
+
+	fmt.Println(yellow("\nNext steps:"))
+	fmt.Println("  autorebase --continue  # after resolving and staging every file")
+	fmt.Println("  autorebase --skip      # drop this commit and carry on")
+	fmt.Println("  autorebase --abort     # go back to where you started")
+}
+
+// unmergedFile is a conflicted path with its two-letter porcelain status
+type unmergedFile struct {
+	Code string
+	Path string
+}
+
+func unmergedFiles() []unmergedFile {
+	var files []unmergedFile
+	// -z keeps paths unquoted; they are relative to the top of the work tree
+	entries := strings.Split(gitOutput("status", "--porcelain", "-z"), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code := entry[:2]
+		if code[0] == 'R' || code[0] == 'C' {
+			// The original path of a rename or copy follows as its own entry
+			i++
+		}
+		if strings.Contains(code, "U") || code == "AA" || code == "DD" {
+			files = append(files, unmergedFile{Code: code, Path: entry[3:]})
+		}
+	}
+	return files
+}
+
+// countMarkers counts the conflict regions left in a working tree file,
+// given by its path from the top of the work tree
+func countMarkers(path string) int {
+	content, err := os.ReadFile(repoPath(path))
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("<<<<<<< ")) {
+			count++
+		}
+	}
+	return count
+}
+
+// filesWithMarkers lists staged files that still contain conflict markers
+func filesWithMarkers() []string {
+	var files []string
+	for _, path := range strings.Split(gitOutput("diff", "--cached", "--name-only", "-z"), "\x00") {
+		if path != "" && countMarkers(path) > 0 {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// repoPath turns a path relative to the top of the work tree, as git reports
+// it, into one that works from any directory inside it
+func repoPath(path string) string {
+	top := strings.TrimSpace(gitOutput("rev-parse", "--show-toplevel"))
+	return filepath.Join(top, filepath.FromSlash(path))
+}
+
+// runRebase runs a git rebase command without ever opening an editor
+func runRebase(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true", "GIT_SEQUENCE_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func currentBranch() string {
+	return strings.TrimSpace(gitOutput("branch", "--show-current"))
+}
+
+func hasUncommittedChanges() bool {
+	return strings.TrimSpace(gitOutput("status", "--porcelain", "--untracked-files=no")) != ""
+}
+
+func refExists(ref string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", ref).Run() == nil
+}
+
+func shortHash(rev string) string {
+	if short := strings.TrimSpace(gitOutput("rev-parse", "--short", rev)); short != "" {
+		return short
+	}
+	return rev
+}
+
+func gitPath(name string) string {
+	return strings.TrimSpace(gitOutput("rev-parse", "--git-path", name))
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func gitConfig(key string) string {
+	return strings.TrimSpace(gitOutput("config", "--get", key))
+}
+
+func gitOutput(args ...string) string {
+	output, _ := exec.Command("git", args...).Output()
+	return string(output)
+}
+
+func runCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode && strings.TrimSpace(message) != "" {
+		fmt.Printf("%s %s\n", yellow("→"), strings.TrimSpace(message))
+	}
+}
+
+func startSpinner(message string) {
+	s = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + message
+	s.Start()
+}
+
+func stopSpinner() {
+	s.Stop()
+}
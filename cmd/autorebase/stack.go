@@ -0,0 +1,485 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amanmehtacode/GitNoob/internal/squash"
+	"github.com/spf13/cobra"
+)
+
+// Stack relationships are remembered in git config so they survive a parent
+// being rewritten or merged:
+//
+//	branch.<name>.stackParent  the branch this one was built on
+//	branch.<name>.stackBase    the parent commit it was last rebased onto
+//	autorebase.restack         the branch of a restack waiting on --continue
+var (
+	stackPush   bool
+	stackParent string
+)
+
+// maxRestackPasses bounds how many branches one restack rebases
+const maxRestackPasses = 50
+
+func newStackCmd() *cobra.Command {
+	stackCmd := &cobra.Command{
+		Use:   "stack",
+		Short: "Show the stack of branches the current branch belongs to",
+		Run:   showStack,
+	}
+	stackCmd.Flags().StringVar(&stackParent, "parent", "", "Record the branch the current branch is stacked on")
+	return stackCmd
+}
+
+func newRestackCmd() *cobra.Command {
+	restackCmd := &cobra.Command{
+		Use:   "restack",
+		Short: "Rebase every branch in the stack onto its updated or merged parent",
+		Run:   restack,
+	}
+	restackCmd.Flags().BoolVar(&stackPush, "push", false, "Push each restacked branch with --force-with-lease")
+	restackCmd.Flags().BoolVar(&cfg.NoFetch, "no-fetch", false, "Do not fetch the remote before restacking")
+	return restackCmd
+}
+
+// stackBranch is one branch in a stack and where it currently stands
+type stackBranch struct {
+	Name         string
+	Parent       string // recorded or detected parent; the trunk for the bottom branch
+	Base         string // parent commit the branch was built on
+	ParentMerged bool   // the parent has landed in the trunk
+	Children     []*stackBranch
+}
+
+// stackModel holds every local branch's stack relationship
+type stackModel struct {
+	Trunk    string
+	Branches map[string]*stackBranch
+}
+
+// onto returns the ref the branch should sit on, skipping parents that were merged
+func (m *stackModel) onto(b *stackBranch) string {
+	parent := b.Parent
+	for parent != m.Trunk && m.merged(parent) {
+		p, ok := m.Branches[parent]
+		if !ok {
+			return m.Trunk
+		}
+		parent = p.Parent
+	}
+	return parent
+}
+
+// merged reports whether a branch has landed in the trunk, either as a merge,
+// as commits whose patches all appear in the trunk, or as a squash merge
+func (m *stackModel) merged(branch string) bool {
+	if _, ok := m.Branches[branch]; !ok {
+		return branch != m.Trunk
+	}
+	if isAncestor(branch, m.Trunk) {
+		return true
+	}
+	if allPatchesIn(m.Trunk, branch) {
+		return true
+	}
+
+	// Squash merge: the branch's whole change as one commit in the trunk
+	return squash.Merged(branch, m.Trunk)
+}
+
+// allPatchesIn reports whether every commit of branch has an equivalent patch in upstream
+func allPatchesIn(upstream, branch string) bool {
+	cherry := nonEmptyLines(gitOutput("cherry", upstream, branch))
+	if len(cherry) == 0 {
+		return false
+	}
+	for _, line := range cherry {
+		if !strings.HasPrefix(line, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// needsRestack reports whether the branch no longer contains the tip of the ref it should sit on
+func (m *stackModel) needsRestack(b *stackBranch) bool {
+	return !isAncestor(m.onto(b), b.Name)
+}
+
+// loadStack builds the stack model from config and ancestry. With record set
+// it also saves the relationships it detects, so later rewrites of a parent
+// can be followed; showing the stack leaves the config alone.
+func loadStack(record bool) *stackModel {
+	remote := defaultRemote()
+	trunk, err := defaultBranch(remote)
+	if err != nil {
+		log.Fatalf(red("Cannot determine the trunk branch: %v"), err)
+	}
+	trunkLocal := strings.TrimPrefix(trunk, remote+"/")
+
+	model := &stackModel{Trunk: trunk, Branches: map[string]*stackBranch{}}
+	names := nonEmptyLines(gitOutput("for-each-ref", "--format=%(refname:short)", "refs/heads"))
+	for _, name := range names {
+		if name != trunkLocal {
+			model.Branches[name] = &stackBranch{Name: name}
+		}
+	}
+
+	for _, b := range model.Branches {
+		b.Parent = gitConfig("branch." + b.Name + ".stackParent")
+		b.Base = gitConfig("branch." + b.Name + ".stackBase")
+		if _, ok := model.Branches[b.Parent]; !ok {
+			var base string
+			b.Parent, base = detectParent(model, b.Name)
+			if base != "" {
+				b.Base = base
+			}
+			if record && b.Parent != trunk {
+				setConfig("branch."+b.Name+".stackParent", b.Parent)
+				setConfig("branch."+b.Name+".stackBase", b.Base)
+			}
+		}
+		if b.Base == "" && isAncestor(b.Parent, b.Name) {
+			b.Base = revParse(b.Parent)
+			if record {
+				setConfig("branch."+b.Name+".stackBase", b.Base)
+			}
+		}
+	}
+
+	// A chain of parents that loops back, e.g. from two --parent settings,
+	// is cut so every stack has a bottom
+	for _, b := range model.Branches {
+		for p, steps := b.Parent, 0; steps < len(model.Branches); steps++ {
+			parent, ok := model.Branches[p]
+			if !ok {
+				break
+			}
+			if parent == b {
+				b.Parent = trunk
+				break
+			}
+			p = parent.Parent
+		}
+	}
+
+	for _, b := range model.Branches {
+		if parent, ok := model.Branches[b.Parent]; ok {
+			parent.Children = append(parent.Children, b)
+			b.ParentMerged = model.merged(b.Parent)
+		}
+	}
+	for _, b := range model.Branches {
+		sort.Slice(b.Children, func(i, j int) bool { return b.Children[i].Name < b.Children[j].Name })
+	}
+	return model
+}
+
+// detectParent finds the nearest local branch the branch was built on: one
+// whose tip is in the branch's history or, when that branch has moved on
+// since, whose earlier tip is, as its reflog remembers. It returns the parent
+// and the commit the branch left it at, empty for the trunk.
+func detectParent(model *stackModel, branch string) (string, string) {
+	parent, base, best := model.Trunk, "", -1
+	tip := revParse(branch)
+	for name := range model.Branches {
+		if name == branch || revParse(name) == tip {
+			continue
+		}
+		point := revParse(name)
+		if !isAncestor(name, branch) {
+			point = strings.TrimSpace(gitOutput("merge-base", "--fork-point", name, branch))
+			// Only a commit made on the candidate counts: every branch forked
+			// from the trunk shares its commits, and a branch created on top
+			// of this one starts at one of its commits
+			if point == "" || point == tip || isAncestor(point, model.Trunk) || point == createdAt(name) {
+				continue
+			}
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(gitOutput("rev-list", "--count", point)))
+		if count > best {
+			parent, base, best = name, point, count
+		}
+	}
+	return parent, base
+}
+
+// createdAt returns the commit a branch was created at, the oldest entry of its reflog
+func createdAt(branch string) string {
+	entries := nonEmptyLines(gitOutput("reflog", "show", "--format=%H", "refs/heads/"+branch, "--"))
+	if len(entries) == 0 {
+		return ""
+	}
+	return entries[len(entries)-1]
+}
+
+// bottom returns the bottom of the stack containing the branch
+func (m *stackModel) bottom(branch string) *stackBranch {
+	b, ok := m.Branches[branch]
+	if !ok {
+		return nil
+	}
+	for {
+		parent, ok := m.Branches[b.Parent]
+		if !ok {
+			return b
+		}
+		b = parent
+	}
+}
+
+// walk visits the branches of a stack from the bottom up
+func (m *stackModel) walk(b *stackBranch, depth int, visit func(*stackBranch, int)) {
+	visit(b, depth)
+	for _, child := range b.Children {
+		m.walk(child, depth+1, visit)
+	}
+}
+
+func showStack(cmd *cobra.Command, args []string) {
+	branch := currentBranch()
+	if branch == "" {
+		log.Fatal(red("HEAD is detached. Check out a branch in the stack first."))
+	}
+
+	if stackParent != "" {
+		if !refExists("refs/heads/"+stackParent) && !refExists(stackParent) {
+			log.Fatalf(red("Unknown branch %s"), stackParent)
+		}
+		setConfig("branch."+branch+".stackParent", stackParent)
+		if isAncestor(stackParent, branch) {
+			setConfig("branch."+branch+".stackBase", revParse(stackParent))
+		}
+		fmt.Println(green(fmt.Sprintf("✓ %s is now stacked on %s", branch, stackParent)))
+	}
+
+	model := loadStack(false)
+	bottom := model.bottom(branch)
+	if bottom == nil {
+		log.Fatalf(red("%s is the trunk branch, not part of a stack"), branch)
+	}
+
+	fmt.Println(yellow(model.Trunk))
+	pending := 0
+	model.walk(bottom, 0, func(b *stackBranch, depth int) {
+		count := strings.TrimSpace(gitOutput("rev-list", "--count", model.onto(b)+".."+b.Name))
+		line := fmt.Sprintf("%s└─ %s (%s commit(s))", strings.Repeat("   ", depth), b.Name, count)
+		if b.Name == branch {
+			line = green(line + " *")
+		}
+		status := ""
+		switch {
+		case model.merged(b.Name):
+			status = yellow("merged into " + model.Trunk)
+		case b.ParentMerged && model.needsRestack(b):
+			status = red(fmt.Sprintf("parent %s merged, needs restack onto %s", b.Parent, model.onto(b)))
+			pending++
+		case model.needsRestack(b):
+			status = red("needs restack")
+			pending++
+		}
+		fmt.Println(strings.TrimRight(line+"  "+status, " "))
+	})
+
+	if pending > 0 {
+		fmt.Println(yellow(fmt.Sprintf("\n%d branch(es) need restacking. Run 'autorebase restack'.", pending)))
+	} else {
+		fmt.Println(green("\n✓ The stack is up to date."))
+	}
+}
+
+func restack(cmd *cobra.Command, args []string) {
+	if _, ok := readRebaseState(); ok {
+		log.Fatal(red("A rebase is in progress. Finish it with 'autorebase --continue' or '--abort' first."))
+	}
+	if hasUncommittedChanges() {
+		log.Fatal(red("You have uncommitted changes. Commit or stash them before restacking."))
+	}
+	branch := currentBranch()
+	if branch == "" {
+		log.Fatal(red("HEAD is detached. Check out a branch in the stack first."))
+	}
+
+	if remote := defaultRemote(); !cfg.NoFetch && hasRemote(remote) {
+		if err := fetchRemote(remote); err != nil {
+			logVerbose(fmt.Sprintf("Could not fetch %s: %v", remote, err))
+		}
+	}
+
+	// Rebase the lowest out-of-date branch onto its parent, then look again:
+	// its children, and siblings on other paths, follow in later passes
+	for pass := 0; pass < maxRestackPasses; pass++ {
+		model := loadStack(true)
+		bottom := model.bottom(branch)
+		if bottom == nil {
+			log.Fatalf(red("%s is the trunk branch, not part of a stack"), branch)
+		}
+
+		var target *stackBranch
+		model.walk(bottom, 0, func(b *stackBranch, depth int) {
+			if target == nil && !model.merged(b.Name) && model.needsRestack(b) {
+				target = b
+			}
+		})
+		if target == nil {
+			returnTo(branch)
+			fmt.Println(green("✓ The stack is up to date."))
+			return
+		}
+
+		tip := revParse(target.Name)
+		if !rebaseBranch(model, target) {
+			os.Exit(1)
+		}
+		if revParse(target.Name) == tip {
+			returnTo(branch)
+			log.Fatalf(red("Restacking %s onto %s moved nothing. Set its parent with 'autorebase stack --parent <branch>' and try again."), target.Name, model.onto(target))
+		}
+	}
+	returnTo(branch)
+	log.Fatalf(red("The stack still needs restacking after %d passes. Check it with 'autorebase stack'."), maxRestackPasses)
+}
+
+// returnTo checks out the branch a restack started from
+func returnTo(branch string) {
+	if err := runCommand("git", "checkout", "--quiet", branch); err != nil {
+		logVerbose(fmt.Sprintf("Could not return to %s: %v", branch, err))
+	}
+}
+
+// rebaseBranch replays one branch of the stack onto the ref it should sit on
+func rebaseBranch(model *stackModel, b *stackBranch) bool {
+	onto := model.onto(b)
+	oldBase := b.Base
+	if oldBase == "" || !isAncestor(oldBase, b.Name) {
+		oldBase = forkPoint(b.Parent, b.Name)
+	}
+	if oldBase == "" {
+		fmt.Println(red(fmt.Sprintf("✗ Cannot tell where %s starts. Set its parent with 'autorebase stack --parent <branch>'.", b.Name)))
+		return false
+	}
+
+	fmt.Println(yellow(fmt.Sprintf("→ Restacking %s onto %s...", b.Name, onto)))
+	if b.ParentMerged {
+		fmt.Printf("  %s was merged, moving %s onto %s\n", b.Parent, b.Name, onto)
+	}
+
+	setConfig("autorebase.restack", b.Name)
+	setConfig("autorebase.restackPush", strconv.FormatBool(stackPush))
+	if onto == model.Trunk {
+		setConfig("autorebase.restackOnto", onto)
+	}
+
+	output, err := runRebase("rebase", "--onto", onto, oldBase, b.Name)
+	if err != nil {
+		if state, ok := readRebaseState(); ok {
+			fmt.Println(red("✗ Restack stopped with conflicts."))
+			printConflictGuidance(state)
+			fmt.Println("  Run 'autorebase restack' again afterwards to restack any remaining branches.")
+			return false
+		}
+		clearRestack()
+		fmt.Println(red(fmt.Sprintf("✗ Restack failed: %v\n%s", err, output)))
+		return false
+	}
+	logVerbose(output)
+	finishRestack()
+	return true
+}
+
+// finishRestack records the new bases of a completed restack and pushes if requested
+func finishRestack() {
+	names := strings.Fields(gitConfig("autorebase.restack"))
+	if len(names) == 0 {
+		return
+	}
+	push := gitConfig("autorebase.restackPush") == "true"
+	if onto := gitConfig("autorebase.restackOnto"); onto != "" {
+		// The bottom branch's parent was merged; it now sits on the trunk
+		unsetConfig("branch." + names[0] + ".stackParent")
+	}
+
+	for i, name := range names {
+		parent := gitConfig("branch." + name + ".stackParent")
+		if i == 0 && parent == "" {
+			parent = gitConfig("autorebase.restackOnto")
+		}
+		if parent != "" && isAncestor(parent, name) {
+			setConfig("branch."+name+".stackBase", revParse(parent))
+		}
+		fmt.Println(green(fmt.Sprintf("✓ Restacked %s", name)))
+
+		if push {
+			remote := gitConfig("branch." + name + ".remote")
+			if remote == "" || remote == "." {
+				remote = defaultRemote()
+			}
+			if err := runCommand("git", "push", "--force-with-lease", remote, name); err != nil {
+				logError(fmt.Sprintf("Failed to push %s", name), err)
+				continue
+			}
+			fmt.Println(green(fmt.Sprintf("✓ Pushed %s to %s", name, remote)))
+		}
+	}
+	clearRestack()
+}
+
+func clearRestack() {
+	for _, key := range []string{"autorebase.restack", "autorebase.restackPush", "autorebase.restackOnto"} {
+		unsetConfig(key)
+	}
+}
+
+// forkPoint finds where a branch left its parent, using the parent's reflog when possible
+func forkPoint(parent, branch string) string {
+	if point := strings.TrimSpace(gitOutput("merge-base", "--fork-point", parent, branch)); point != "" {
+		return point
+	}
+	return strings.TrimSpace(gitOutput("merge-base", parent, branch))
+}
+
+func hasRemote(remote string) bool {
+	for _, name := range strings.Fields(gitOutput("remote")) {
+		if name == remote {
+			return true
+		}
+	}
+	return false
+}
+
+func isAncestor(ancestor, descendant string) bool {
+	return runCommand("git", "merge-base", "--is-ancestor", ancestor, descendant) == nil
+}
+
+func revParse(rev string) string {
+	return strings.TrimSpace(gitOutput("rev-parse", "--verify", "--quiet", rev))
+}
+
+func setConfig(key, value string) {
+	if err := runCommand("git", "config", key, value); err != nil {
+		logVerbose(fmt.Sprintf("Could not set %s: %v", key, err))
+	}
+}
+
+func unsetConfig(key string) {
+	_ = runCommand("git", "config", "--unset", key)
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+func logError(message string, err error) {
+	log.Printf("%s %s: %v", red("✗"), message, err)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultHeader starts a new changelog
+const defaultHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).`
+
+var (
+	// headingPattern matches "## [1.2.0] - 2024-05-06" and "## 1.2.0"
+	headingPattern = regexp.MustCompile(`^## \[?([^\]\s]+)\]?`)
+	// linkPattern matches a link reference such as "[1.2.0]: https://..."
+	linkPattern = regexp.MustCompile(`^\[([^\]]+)\]:\s`)
+)
+
+// changelogFile is a Keep a Changelog file taken apart
+type changelogFile struct {
+	Header   string
+	Sections []string // each starting with its "## " heading
+	Links    []string // link references at the end of the file
+}
+
+func parseChangelog(text string) changelogFile {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	// Link references are the last lines of the file
+	end := len(lines)
+	for end > 0 && (linkPattern.MatchString(lines[end-1]) || strings.TrimSpace(lines[end-1]) == "") {
+		end--
+	}
+	var f changelogFile
+	for _, line := range lines[end:] {
+		if line != "" {
+			f.Links = append(f.Links, line)
+		}
+	}
+
+	var current []string
+	flush := func() {
+		if current != nil {
+			f.Sections = append(f.Sections, strings.TrimRight(strings.Join(current, "\n"), "\n "))
+		}
+	}
+	var header []string
+	for _, line := range lines[:end] {
+		switch {
+		case strings.HasPrefix(line, "## "):
+			flush()
+			current = []string{line}
+		case current != nil:
+			current = append(current, line)
+		default:
+			header = append(header, line)
+		}
+	}
+	flush()
+	f.Header = strings.TrimSpace(strings.Join(header, "\n"))
+	return f
+}
+
+func (f changelogFile) String() string {
+	parts := []string{f.Header}
+	parts = append(parts, f.Sections...)
+	if len(f.Links) > 0 {
+		parts = append(parts, strings.Join(f.Links, "\n"))
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// find returns the index of the section for a version, or -1
+func (f changelogFile) find(version string) int {
+	for i, s := range f.Sections {
+		if match := headingPattern.FindStringSubmatch(s); match != nil && strings.EqualFold(match[1], version) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setLink adds or replaces the link reference for a version; empty url
+// removes it
+func (f *changelogFile) setLink(version, url string) {
+	line := fmt.Sprintf("[%s]: %s", version, url)
+	for i, existing := range f.Links {
+		if match := linkPattern.FindStringSubmatch(existing); match != nil && strings.EqualFold(match[1], version) {
+			if url == "" {
+				f.Links = append(f.Links[:i], f.Links[i+1:]...)
+			} else {
+				f.Links[i] = line
+			}
+			return
+		}
+	}
+	if url != "" {
+		// Newest first, like the sections
+		f.Links = append([]string{line}, f.Links...)
+	}
+}
+
+// updateChangelog puts a section into the changelog file, replacing the one
+// for the same version. It reports whether the file changed.
+func updateChangelog(path string, s section) (bool, error) {
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	f := parseChangelog(string(old))
+	if f.Header == "" {
+		f.Header = defaultHeader
+	}
+
+	text := strings.TrimRight(s.Text, "\n")
+	if i := f.find(s.Version); i >= 0 {
+		f.Sections[i] = text
+	} else {
+		if s.Version != unreleased {
+			// The unreleased changes are in this release now
+			if i := f.find(unreleased); i >= 0 {
+				f.Sections = append(f.Sections[:i], f.Sections[i+1:]...)
+				f.setLink(unreleased, "")
+				fmt.Println(yellow(fmt.Sprintf("Replacing the [%s] section with [%s]", unreleased, s.Version)))
+			}
+		}
+		f.Sections = append([]string{text}, f.Sections...)
+	}
+	if s.Link != "" {
+		f.setLink(s.Version, s.Link)
+	}
+
+	updated := f.String()
+	if updated == string(old) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, []byte(updated), 0644)
+}
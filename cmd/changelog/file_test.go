@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseChangelog(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want changelogFile
+	}{
+		{name: "empty", text: "", want: changelogFile{}},
+		{
+			name: "header, sections and links",
+			text: "# Changelog\n\nIntro.\n\n## [Unreleased]\n\n### Added\n\n- b\n\n## [1.0.0] - 2024-05-06\n\n- a\n\n" +
+				"[Unreleased]: https://example.com/compare/v1.0.0...HEAD\n[1.0.0]: https://example.com/releases/v1.0.0\n",
+			want: changelogFile{
+				Header:   "# Changelog\n\nIntro.",
+				Sections: []string{"## [Unreleased]\n\n### Added\n\n- b", "## [1.0.0] - 2024-05-06\n\n- a"},
+				Links:    []string{"[Unreleased]: https://example.com/compare/v1.0.0...HEAD", "[1.0.0]: https://example.com/releases/v1.0.0"},
+			},
+		},
+		{
+			name: "headings without brackets",
+			text: "# Changelog\n\n## 1.1.0\n- c\n## 1.0.0\n- a\n",
+			want: changelogFile{Header: "# Changelog", Sections: []string{"## 1.1.0\n- c", "## 1.0.0\n- a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseChangelog(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseChangelog = %#v, want %#v", got, tt.want)
+			}
+			if len(got.Sections) > 0 {
+				if again := parseChangelog(got.String()); !reflect.DeepEqual(again, got) {
+					t.Errorf("parsing the written file again = %#v, want %#v", again, got)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateChangelog(t *testing.T) {
+	unreleasedSection := section{Version: unreleased, Text: "## [Unreleased]\n\n### Fixed\n\n- a fix\n",
+		Link: "https://example.com/compare/v1.0.0...HEAD"}
+	release := section{Version: "1.1.0", Text: "## [1.1.0] - 2024-06-01\n\n### Fixed\n\n- a fix\n",
+		Link: "https://example.com/compare/v1.0.0...v1.1.0"}
+	existing := defaultHeader + "\n\n## [1.0.0] - 2024-05-06\n\n- first\n\n[1.0.0]: https://example.com/releases/v1.0.0\n"
+
+	tests := []struct {
+		name     string
+		existing string // empty for no file
+		sections []section
+		want     string
+		changed  bool // whether the last update changed the file
+	}{
+		{
+			name:     "new file",
+			sections: []section{unreleasedSection},
+			want: defaultHeader + "\n\n## [Unreleased]\n\n### Fixed\n\n- a fix\n\n" +
+				"[Unreleased]: https://example.com/compare/v1.0.0...HEAD\n",
+			changed: true,
+		},
+		{
+			name:     "re-run changes nothing",
+			existing: existing,
+			sections: []section{release, release},
+			want: defaultHeader + "\n\n## [1.1.0] - 2024-06-01\n\n### Fixed\n\n- a fix\n\n## [1.0.0] - 2024-05-06\n\n- first\n\n" +
+				"[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[1.0.0]: https://example.com/releases/v1.0.0\n",
+			changed: false,
+		},
+		{
+			name:     "re-run replaces the section",
+			existing: existing,
+			sections: []section{unreleasedSection, {Version: unreleased, Text: "## [Unreleased]\n\n- another fix\n"}},
+			want: defaultHeader + "\n\n## [Unreleased]\n\n- another fix\n\n## [1.0.0] - 2024-05-06\n\n- first\n\n" +
+				"[Unreleased]: https://example.com/compare/v1.0.0...HEAD\n[1.0.0]: https://example.com/releases/v1.0.0\n",
+			changed: true,
+		},
+		{
+			name:     "release takes the place of unreleased",
+			existing: existing,
+			sections: []section{unreleasedSection, release},
+			want: defaultHeader + "\n\n## [1.1.0] - 2024-06-01\n\n### Fixed\n\n- a fix\n\n## [1.0.0] - 2024-05-06\n\n- first\n\n" +
+				"[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[1.0.0]: https://example.com/releases/v1.0.0\n",
+			changed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var changed bool
+			for _, s := range tt.sections {
+				var err error
+				if changed, err = updateChangelog(path, s); err != nil {
+					t.Fatalf("updateChangelog(%s) failed: %v", s.Version, err)
+				}
+			}
+			if changed != tt.changed {
+				t.Errorf("last update changed the file: %v, want %v", changed, tt.changed)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CHANGELOG.md =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	From        string
+	To          string
+	Version     string
+	Date        string
+	File        string
+	All         bool
+	Stdout      bool
+	Remote      string
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Add the changes between two tags to CHANGELOG.md",
+		Long: `Add the changes between two tags to CHANGELOG.md.
+
+The commits between --from and --to (by default the previous tag and HEAD) are
+grouped by their Conventional Commit type into the sections of Keep a
+Changelog: feat goes under Added, fix under Fixed, perf and refactor under
+Changed, and deprecate, remove and security types under their own sections.
+Breaking changes, marked with "!" or a BREAKING CHANGE footer, come first in
+their section. Other types are left out unless --all is given.
+
+The new section is named after the tag at --to, or [Unreleased] when there is
+none, and is added at the top of the file. Running the command again for the
+same range replaces that section instead of adding another, and releasing a
+version replaces the [Unreleased] section. With --stdout the section is
+printed instead, e.g. as release notes.`,
+		Args: cobra.NoArgs,
+		Run:  changelog,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().StringVarP(&cfg.From, "from", "f", "", "Start after this tag or commit (default: the tag before --to)")
+	rootCmd.Flags().StringVarP(&cfg.To, "to", "t", "HEAD", "End at this tag or commit")
+	rootCmd.Flags().StringVar(&cfg.Version, "version", "", "Name of the section (default: the tag at --to, or Unreleased)")
+	rootCmd.Flags().StringVarP(&cfg.Date, "date", "d", "", "Release date, YYYY-MM-DD (default: the date of the tag, or today)")
+	rootCmd.Flags().StringVarP(&cfg.File, "file", "o", "CHANGELOG.md", "Changelog file to update")
+	rootCmd.Flags().BoolVarP(&cfg.All, "all", "a", false, "Include docs, test, build, ci, style, chore and other commits under Changed")
+	rootCmd.Flags().BoolVar(&cfg.Stdout, "stdout", false, "Print the section instead of writing the file")
+	rootCmd.Flags().StringVarP(&cfg.Remote, "remote", "r", "origin", "Remote whose GitHub URL is used for links")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func changelog(cmd *cobra.Command, args []string) {
+	if _, err := gitOutput("rev-parse", "--git-dir"); err != nil {
+		log.Fatal(red("Not a Git repository."))
+	}
+
+	r, err := resolveRange()
+	if err != nil {
+		log.Fatalf(red("%v"), err)
+	}
+	commits, err := loadCommits(r)
+	if err != nil {
+		log.Fatalf(red("Failed to read the commits: %v"), err)
+	}
+	if len(commits) == 0 {
+		log.Fatalf(red("No commits in %s"), r)
+	}
+	logVerbose(fmt.Sprintf("%d commit(s) in %s", len(commits), r))
+
+	links := githubLinks(cfg.Remote)
+	section := renderRelease(newRelease(r, commits), links)
+	if cfg.Stdout {
+		fmt.Print(section.Text)
+		return
+	}
+
+	changed, err := updateChangelog(cfg.File, section)
+	if err != nil {
+		log.Fatalf(red("Failed to update %s: %v"), cfg.File, err)
+	}
+	if !changed {
+		fmt.Println(green(fmt.Sprintf("✓ %s is already up to date for [%s]", cfg.File, section.Version)))
+		return
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Wrote [%s] to %s (%d commit(s) since %s)", section.Version, cfg.File, len(commits), r.fromName())))
+}
+
+// gitOutput runs a git command and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logVerbose("git " + strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow("→"), message)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/amanmehtacode/GitNoob/internal/conventional"
+)
+
+// unreleased names the section for changes that are not tagged yet
+const unreleased = "Unreleased"
+
+// Keep a Changelog sections, in the order they are written
+const (
+	sectionAdded      = "Added"
+	sectionChanged    = "Changed"
+	sectionDeprecated = "Deprecated"
+	sectionRemoved    = "Removed"
+	sectionFixed      = "Fixed"
+	sectionSecurity   = "Security"
+)
+
+var sectionOrder = []string{sectionAdded, sectionChanged, sectionDeprecated, sectionRemoved, sectionFixed, sectionSecurity}
+
+// typeSections maps Conventional Commit types to changelog sections; other
+// types only appear with --all. Any commit scoped "security" goes under
+// Security.
+var typeSections = map[string]string{
+	"feat":       sectionAdded,
+	"perf":       sectionChanged,
+	"refactor":   sectionChanged,
+	"revert":     sectionChanged,
+	"deprecate":  sectionDeprecated,
+	"deprecated": sectionDeprecated,
+	"remove":     sectionRemoved,
+	"removed":    sectionRemoved,
+	"fix":        sectionFixed,
+	"security":   sectionSecurity,
+}
+
+var (
+	githubRemotePattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+	githubIssuePattern  = regexp.MustCompile(`^#(\d+)$`)
+	// pullRequestSuffix matches the " (#12)" GitHub adds to squash-merged subjects
+	pullRequestSuffix = regexp.MustCompile(`\s*\(#\d+\)$`)
+)
+
+// commitRange is the span of history a section covers
+type commitRange struct {
+	From  string // empty for the start of history
+	To    string
+	ToTag string // tag pointing at To, if any
+}
+
+func (r commitRange) String() string {
+	return r.fromName() + ".." + r.To
+}
+
+func (r commitRange) fromName() string {
+	if r.From == "" {
+		return "the first commit"
+	}
+	return r.From
+}
+
+// revisions is the git log argument for the range
+func (r commitRange) revisions() string {
+	if r.From == "" {
+		return r.To
+	}
+	return r.From + ".." + r.To
+}
+
+// commit is one commit in the changelog
+type commit struct {
+	Hash  string
+	Short string
+	conventional.Message
+}
+
+// release is the changelog section being written
+type release struct {
+	Version  string
+	Date     string
+	Range    commitRange
+	Sections map[string][]commit
+}
+
+// section is a rendered release, ready to be put into the changelog
+type section struct {
+	Version string
+	Text    string
+	Link    string // compare URL for the link reference, empty without GitHub
+}
+
+// resolveRange works out the commits to describe from --from and --to
+func resolveRange() (commitRange, error) {
+	r := commitRange{To: cfg.To}
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", r.To+"^{commit}"); err != nil {
+		return r, fmt.Errorf("%s is not a tag or commit", r.To)
+	}
+	r.ToTag, _ = gitOutput("describe", "--tags", "--exact-match", r.To)
+
+	r.From = cfg.From
+	if r.From == "" {
+		// The closest tag before --to; none means the whole history
+		r.From, _ = gitOutput("describe", "--tags", "--abbrev=0", r.To+"^")
+		return r, nil
+	}
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", r.From+"^{commit}"); err != nil {
+		return r, fmt.Errorf("%s is not a tag or commit", r.From)
+	}
+	return r, nil
+}
+
+// loadCommits reads and parses the commits in the range, oldest first
+func loadCommits(r commitRange) ([]commit, error) {
+	output, err := gitOutput("log", "--reverse", "--no-merges", "--format=%H%x1f%h%x1f%s%x1f%b%x1e", r.revisions())
+	if err != nil {
+		return nil, err
+	}
+	var commits []commit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, commit{Hash: fields[0], Short: fields[1], Message: conventional.Parse(fields[2], fields[3])})
+	}
+	return commits, nil
+}
+
+// newRelease sorts the commits into the changelog sections
+func newRelease(r commitRange, commits []commit) release {
+	rel := release{Version: cfg.Version, Date: cfg.Date, Range: r, Sections: map[string][]commit{}}
+	if rel.Version == "" {
+		rel.Version = unreleased
+		if r.ToTag != "" {
+			rel.Version = versionName(r.ToTag)
+		}
+	}
+	if rel.Date == "" && rel.Version != unreleased {
+		rel.Date = time.Now().Format("2006-01-02")
+		if r.ToTag != "" {
+			if date, err := gitOutput("for-each-ref", "--format=%(creatordate:short)", "refs/tags/"+r.ToTag); err == nil && date != "" {
+				rel.Date = date
+			}
+		}
+	}
+
+	var breaking, rest []commit
+	for _, c := range commits {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	// Breaking changes go first in their section
+	for _, c := range append(breaking, rest...) {
+		name, ok := typeSections[c.Type]
+		if c.Scope == "security" {
+			name, ok = sectionSecurity, true
+		}
+		if !ok && (c.Breaking || cfg.All) {
+			name, ok = sectionChanged, true
+		}
+		if ok {
+			rel.Sections[name] = append(rel.Sections[name], c)
+		}
+	}
+	return rel
+}
+
+// versionName drops the "v" of tags like v1.2.3, as Keep a Changelog does
+func versionName(tag string) string {
+	if len(tag) > 1 && tag[0] == 'v' && tag[1] >= '0' && tag[1] <= '9' {
+		return tag[1:]
+	}
+	return tag
+}
+
+// githubLinks returns the web URL of the GitHub repository behind a remote,
+// or "" when it is not on GitHub
+func githubLinks(remote string) string {
+	url, err := gitOutput("remote", "get-url", remote)
+	if err != nil {
+		return ""
+	}
+	match := githubRemotePattern.FindStringSubmatch(url)
+	if match == nil {
+		return ""
+	}
+	return fmt.Sprintf("https://github.com/%s/%s", match[1], match[2])
+}
+
+// renderRelease writes a release as a Keep a Changelog section
+func renderRelease(rel release, links string) section {
+	var b strings.Builder
+	b.WriteString("## [" + rel.Version + "]")
+	if rel.Date != "" {
+		b.WriteString(" - " + rel.Date)
+	}
+	b.WriteString("\n")
+
+	empty := true
+	for _, name := range sectionOrder {
+		commits := rel.Sections[name]
+		if len(commits) == 0 {
+			continue
+		}
+		empty = false
+		fmt.Fprintf(&b, "\n### %s\n\n", name)
+		for _, c := range commits {
+			b.WriteString(entry(c, links))
+		}
+	}
+	if empty {
+		b.WriteString("\nNo notable changes.\n")
+	}
+
+	s := section{Version: rel.Version, Text: b.String()}
+	if links != "" {
+		switch to := rel.Range.ToTag; {
+		case rel.Version == unreleased && rel.Range.From != "":
+			s.Link = fmt.Sprintf("%s/compare/%s...HEAD", links, rel.Range.From)
+		case to != "" && rel.Range.From != "":
+			s.Link = fmt.Sprintf("%s/compare/%s...%s", links, rel.Range.From, to)
+		case to != "":
+			s.Link = fmt.Sprintf("%s/releases/tag/%s", links, to)
+		}
+	}
+	return s
+}
+
+// entry formats one commit as a list item, with its breaking change notes
+// below it
+func entry(c commit, links string) string {
+	var b strings.Builder
+	b.WriteString("- ")
+	if c.Breaking {
+		b.WriteString("**BREAKING:** ")
+	}
+	if c.Scope != "" {
+		b.WriteString("**" + c.Scope + ":** ")
+	}
+	// The reference is listed with the others below
+	b.WriteString(pullRequestSuffix.ReplaceAllString(c.Description, ""))
+
+	refs := []string{c.Short}
+	if links != "" {
+		refs[0] = fmt.Sprintf("[%s](%s/commit/%s)", c.Short, links, c.Hash)
+	}
+	for _, issue := range c.Issues {
+		if match := githubIssuePattern.FindStringSubmatch(issue); match != nil && links != "" {
+			issue = fmt.Sprintf("[%s](%s/issues/%s)", issue, links, match[1])
+		}
+		refs = append(refs, issue)
+	}
+	b.WriteString(" (" + strings.Join(refs, ", ") + ")\n")
+
+	for _, note := range c.BreakingNotes() {
+		b.WriteString("  - " + note + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amanmehtacode/GitNoob/internal/conventional"
+)
+
+// Commit is one commit in the diary
+type Commit struct {
+	Repo        string                 `json:"repo"`
+	Hash        string                 `json:"hash"`
+	Short       string                 `json:"short"`
+	Author      string                 `json:"author"`
+	Email       string                 `json:"email"`
+	Date        time.Time              `json:"date"`
+	Subject     string                 `json:"subject"`
+	Body        string                 `json:"body,omitempty"`
+	Type        string                 `json:"type"` // Conventional Commit type, "other" when the subject does not follow it
+	Scope       string                 `json:"scope,omitempty"`
+	Breaking    bool                   `json:"breaking,omitempty"`
+	Description string                 `json:"description"` // subject without the type and scope
+	Trailers    []conventional.Trailer `json:"trailers,omitempty"`
+	Issues      []string               `json:"issues,omitempty"` // referenced issues, e.g. #12 or PROJ-34
+	Files       int                    `json:"files"`
+	Insertions  int                    `json:"insertions"`
+	Deletions   int                    `json:"deletions"`
+}
+
+// Trailer returns the values of the trailers with the given key, ignoring case
+func (c Commit) Trailer(key string) []string {
+	var values []string
+	for _, t := range c.Trailers {
+		if strings.EqualFold(t.Key, key) {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// Summary returns the description without the " (#12)" of a squash merge,
+// for templates that list the issues after it
+func (c Commit) Summary() string {
+	return pullRequestSuffix.ReplaceAllString(c.Description, "")
+}
+
+// Merge is a merge commit that brought a branch in
+type Merge struct {
+	Repo        string    `json:"repo"`
+	Hash        string    `json:"hash"`
+	Short       string    `json:"short"`
+	Author      string    `json:"author"`
+	Date        time.Time `json:"date"`
+	Subject     string    `json:"subject"`
+	Branch      string    `json:"branch"`
+	Into        string    `json:"into,omitempty"` // empty when git left it out, i.e. the default branch
+	PullRequest string    `json:"pullRequest,omitempty"`
+}
+
+func (c Commit) stats() Stats {
+	return Stats{Commits: 1, Files: c.Files, Insertions: c.Insertions, Deletions: c.Deletions}
+}
+
+// Stats are the diff stats of a commit or a group of commits
+type Stats struct {
+	Commits    int `json:"commits"`
+	Files      int `json:"files"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+func (s *Stats) add(o Stats) {
+	s.Commits += o.Commits
+	s.Files += o.Files
+	s.Insertions += o.Insertions
+	s.Deletions += o.Deletions
+}
+
+var (
+	// mergeBranchPattern and pullRequestPattern match the subjects git and
+	// GitHub give merge commits
+	mergeBranchPattern = regexp.MustCompile(`^Merge (?:remote-tracking )?branch '([^']+)'(?: of \S+)?(?: into (\S+))?`)
+	pullRequestPattern = regexp.MustCompile(`^Merge pull request (#\d+) from [^/\s]+/(\S+)`)
+	// pullRequestSuffix matches the " (#12)" GitHub adds to squash-merged subjects
+	pullRequestSuffix = regexp.MustCompile(`\s*\(#\d+\)$`)
+)
+
+// findRepos resolves each argument to its repository, or searches it for
+// repositories up to --depth levels down when it is not inside one
+func findRepos(args []string) []string {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	seen := map[string]bool{}
+	var repos []string
+	addRepo := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			repos = append(repos, path)
+		}
+	}
+
+	for _, arg := range args {
+		if top, err := gitOutput(arg, "rev-parse", "--show-toplevel"); err == nil {
+			addRepo(top)
+			continue
+		}
+		root, err := filepath.Abs(arg)
+		if err != nil {
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+				addRepo(path)
+				return filepath.SkipDir
+			}
+			rel, _ := filepath.Rel(root, path)
+			if path != root && (strings.HasPrefix(d.Name(), ".") || strings.Count(rel, string(filepath.Separator)) >= cfg.Depth-1) {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// defaultAuthor is the configured user of a repository, matched by email when set
+func defaultAuthor(repo string) string {
+	if email, err := gitOutput(repo, "config", "user.email"); err == nil && email != "" {
+		return email
+	}
+	name, _ := gitOutput(repo, "config", "user.name")
+	return name
+}
+
+// authorFilter is the --author pattern for a repository, empty for everyone
+func authorFilter(repo string) string {
+	switch {
+	case cfg.AllAuthors:
+		return ""
+	case cfg.Author != "":
+		return cfg.Author
+	}
+	return defaultAuthor(repo)
+}
+
+// loadCommits reads the commits of one repository in the date range
+func loadCommits(repo string, since, until time.Time) ([]Commit, error) {
+	args := []string{"log", "--branches", "--numstat", "--date-order",
+		"--since=" + since.Format(time.RFC3339), "--until=" + until.Format(time.RFC3339),
+		"--format=%x1e%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1f"}
+	if !cfg.Merges {
+		args = append(args, "--no-merges")
+	}
+	if author := authorFilter(repo); author != "" {
+		args = append(args, "--author="+author)
+	}
+	output, err := gitOutput(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(repo)
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 8 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[4])
+		c := Commit{
+			Repo:    name,
+			Hash:    fields[0],
+			Short:   fields[1],
+			Author:  fields[2],
+			Email:   fields[3],
+			Date:    date.Local(),
+			Subject: fields[5],
+			Body:    strings.TrimSpace(fields[6]),
+		}
+		c.Files, c.Insertions, c.Deletions = numstat(fields[7])
+		// Author dates can fall outside a range git filtered by committer date
+		if c.Date.Before(since) || c.Date.After(until) {
+			continue
+		}
+		m := conventional.Parse(c.Subject, c.Body)
+		c.Type, c.Scope, c.Breaking, c.Description = m.Type, m.Scope, m.Breaking, m.Description
+		c.Trailers, c.Issues = m.Trailers, m.Issues
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// numstat sums the files, insertions and deletions in the output of git log
+// --numstat for one commit
+func numstat(output string) (files, insertions, deletions int) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		files++
+		// Binary files show "-" instead of line counts
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		insertions += added
+		deletions += deleted
+	}
+	return files, insertions, deletions
+}
+
+// loadMerges reads the merge commits of one repository in the date range
+// that say which branch they merged
+func loadMerges(repo string, since, until time.Time) []Merge {
+	args := []string{"log", "--branches", "--merges",
+		"--since=" + since.Format(time.RFC3339), "--until=" + until.Format(time.RFC3339),
+		"--format=%H%x1f%h%x1f%an%x1f%aI%x1f%s"}
+	if author := authorFilter(repo); author != "" {
+		args = append(args, "--author="+author)
+	}
+	output, err := gitOutput(repo, args...)
+	if err != nil {
+		return nil
+	}
+
+	var merges []Merge
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		m := Merge{Repo: filepath.Base(repo), Hash: fields[0], Short: fields[1], Author: fields[2], Date: date.Local(), Subject: fields[4]}
+		if match := mergeBranchPattern.FindStringSubmatch(m.Subject); match != nil {
+			m.Branch, m.Into = match[1], match[2]
+		} else if match := pullRequestPattern.FindStringSubmatch(m.Subject); match != nil {
+			m.PullRequest, m.Branch = match[1], match[2]
+		} else {
+			continue
+		}
+		merges = append(merges, m)
+	}
+	return merges
+}
+
+// gitOutput runs a git command in dir and returns its trimmed stdout
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
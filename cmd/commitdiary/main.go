@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Since       string
+	Until       string
+	Author      string
+	AllAuthors  bool
+	Merges      bool
+	Group       []string
+	Format      string
+	Output      string
+	Template    string
+	Depth       int
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+
+	// multipleRepos is set when the diary covers more than one repository
+	multipleRepos bool
+)
+
+// renderers are the output formats for --format
+var renderers = map[string]func(io.Writer, Report) error{
+	"markdown": renderMarkdown,
+	"md":       renderMarkdown,
+	"json":     renderJSON,
+	"text":     renderText,
+	"txt":      renderText,
+}
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "commitdiary [repository or directory...]",
+		Short: "Write a diary of your commits across repositories",
+		Long: `Write a diary of your commits across repositories.
+
+Commits on all local branches between --since and --until are collected from
+each repository given, or from the repositories found under a directory. By
+default only your own commits (user.email of each repository) are included.
+Commits are grouped by day unless --group says otherwise; groups can be
+nested, e.g. --group repo,day or --group day,type, and each group shows its
+diff stats.
+
+Dates can be YYYY-MM-DD, today, yesterday, lastworkday, now, or a number of
+days or weeks ago such as 7d or 2w.
+
+With --template the diary is rendered through a Go text/template instead:
+standup, weekly and changelog are built in, and a file of the same name in
+the templates directory (see commitdiary templates) replaces the built-in
+one. A path to any template file works too.`,
+		Args: cobra.ArbitraryArgs,
+		Run:  commitDiary,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().StringVarP(&cfg.Since, "since", "s", "today", "First day to include")
+	rootCmd.Flags().StringVarP(&cfg.Until, "until", "u", "now", "Last day to include")
+	rootCmd.Flags().StringVarP(&cfg.Author, "author", "a", "", "Only commits by this author, name or email pattern (default: the configured user)")
+	rootCmd.Flags().BoolVar(&cfg.AllAuthors, "all-authors", false, "Include commits by everyone")
+	rootCmd.Flags().BoolVar(&cfg.Merges, "merges", false, "Include merge commits")
+	rootCmd.Flags().StringSliceVarP(&cfg.Group, "group", "g", []string{groupDay}, "Group by repo, day and/or type, outermost first; \"none\" for a flat list")
+	rootCmd.Flags().StringVarP(&cfg.Format, "format", "f", "markdown", "Output format: markdown, json or text")
+	rootCmd.Flags().StringVarP(&cfg.Output, "output", "o", "", "Write the diary to this file instead of standard output")
+	rootCmd.Flags().StringVarP(&cfg.Template, "template", "t", "", "Render with a template: standup, weekly, changelog or a file path")
+	rootCmd.Flags().IntVarP(&cfg.Depth, "depth", "d", 2, "How many directory levels to search for repositories")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.MarkFlagsMutuallyExclusive("author", "all-authors")
+	rootCmd.MarkFlagsMutuallyExclusive("template", "format")
+	rootCmd.AddCommand(newTemplatesCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func commitDiary(cmd *cobra.Command, args []string) {
+	render, ok := renderers[strings.ToLower(cfg.Format)]
+	if !ok {
+		log.Fatalf(red("Unknown format %q; use markdown, json or text"), cfg.Format)
+	}
+	if cfg.Template != "" {
+		tmpl, err := loadTemplate(cfg.Template)
+		if err != nil {
+			log.Fatalf(red("%v"), err)
+		}
+		render = tmpl.render
+		// The built-in templates cover a natural period unless told otherwise
+		if since, ok := templateSince[cfg.Template]; ok && !cmd.Flags().Changed("since") {
+			cfg.Since = since
+		}
+	}
+	if len(cfg.Group) == 1 && cfg.Group[0] == "none" {
+		cfg.Group = nil
+	}
+	for _, g := range cfg.Group {
+		if g != groupRepo && g != groupDay && g != groupType {
+			log.Fatalf(red("Unknown grouping %q; use repo, day or type"), g)
+		}
+	}
+
+	since, err := parseDate(cfg.Since, false)
+	if err != nil {
+		log.Fatalf(red("Invalid --since: %v"), err)
+	}
+	until, err := parseDate(cfg.Until, true)
+	if err != nil {
+		log.Fatalf(red("Invalid --until: %v"), err)
+	}
+	if until.Before(since) {
+		log.Fatal(red("--until is before --since"))
+	}
+
+	repos := findRepos(args)
+	if len(repos) == 0 {
+		log.Fatal(red("No Git repositories found."))
+	}
+	multipleRepos = len(repos) > 1
+
+	var commits []Commit
+	var merges []Merge
+	for _, repo := range repos {
+		found, err := loadCommits(repo, since, until)
+		if err != nil {
+			log.Printf(yellow("Skipping %s: %v"), repo, err)
+			continue
+		}
+		logVerbose(fmt.Sprintf("%s: %d commits", repo, len(found)))
+		commits = append(commits, found...)
+		merges = append(merges, loadMerges(repo, since, until)...)
+	}
+	if len(commits) == 0 && cfg.Format != "json" && cfg.Template == "" {
+		fmt.Fprintln(os.Stderr, yellow(fmt.Sprintf("No commits found between %s and %s.", since.Format("2006-01-02 15:04"), until.Format("2006-01-02 15:04"))))
+		return
+	}
+
+	author := cfg.Author
+	switch {
+	case cfg.AllAuthors:
+		author = "everyone"
+	case author == "":
+		author = defaultAuthor(repos[0])
+	}
+	var out bytes.Buffer
+	if err := render(&out, newReport(commits, merges, repos, since, until, author)); err != nil {
+		log.Fatalf(red("Failed to write the diary: %v"), err)
+	}
+
+	if cfg.Output == "" {
+		os.Stdout.Write(out.Bytes())
+		return
+	}
+	if err := os.WriteFile(cfg.Output, out.Bytes(), 0644); err != nil {
+		log.Fatalf(red("Failed to write %s: %v"), cfg.Output, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Commit diary written to %s", cfg.Output)))
+}
+
+// parseDate understands YYYY-MM-DD, today, yesterday, lastworkday, now and
+// Nd/Nw (days or weeks ago). Days start at midnight; with endOfDay a day
+// includes all of it.
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var day time.Time
+	switch value = strings.ToLower(strings.TrimSpace(value)); {
+	case value == "now":
+		return now, nil
+	case value == "today":
+		day = midnight
+	case value == "yesterday":
+		day = midnight.AddDate(0, 0, -1)
+	case value == "lastworkday":
+		day = midnight.AddDate(0, 0, -1)
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, -1)
+		}
+	case strings.HasSuffix(value, "d") || strings.HasSuffix(value, "w"):
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("%q is not a number of days or weeks", value)
+		}
+		if strings.HasSuffix(value, "w") {
+			n *= 7
+		}
+		day = midnight.AddDate(0, 0, -n)
+	default:
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD, today, yesterday, now, 7d, 2w)", value)
+		}
+		day = parsed
+	}
+
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return day, nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow("→"), message)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amanmehtacode/GitNoob/internal/conventional"
+)
+
+// Ways to group commits, in the order given to --group
+const (
+	groupRepo = "repo"
+	groupDay  = "day"
+	groupType = "type"
+)
+
+var typeTitles = map[string]string{
+	"feat":     "Features",
+	"fix":      "Bug fixes",
+	"perf":     "Performance",
+	"refactor": "Refactoring",
+	"docs":     "Documentation",
+	"test":     "Tests",
+	"build":    "Build",
+	"ci":       "CI",
+	"style":    "Style",
+	"chore":    "Chores",
+	"revert":   "Reverts",
+	"other":    "Other changes",
+}
+
+// Report is the whole diary, also the JSON output
+type Report struct {
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+	Author  string    `json:"author"`
+	Repos   []string  `json:"repos"`
+	Stats   Stats     `json:"stats"`
+	Groups  []Group   `json:"groups,omitempty"`
+	Commits []Commit  `json:"commits,omitempty"` // only when not grouped
+	Merges  []Merge   `json:"merges,omitempty"`
+}
+
+// Group is one repository, day or commit type, holding either nested groups
+// or, at the innermost level, the commits
+type Group struct {
+	Kind    string   `json:"kind"`
+	Key     string   `json:"key"`
+	Title   string   `json:"title"`
+	Stats   Stats    `json:"stats"`
+	Groups  []Group  `json:"groups,omitempty"`
+	Commits []Commit `json:"commits,omitempty"`
+}
+
+func newReport(commits []Commit, merges []Merge, repos []string, since, until time.Time, author string) Report {
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Date.Before(commits[j].Date) })
+	sort.SliceStable(merges, func(i, j int) bool { return merges[i].Date.Before(merges[j].Date) })
+	r := Report{Since: since, Until: until, Author: author, Repos: repos, Merges: merges}
+	for _, c := range commits {
+		r.Stats.add(c.stats())
+	}
+	if len(cfg.Group) == 0 {
+		r.Commits = commits
+	} else {
+		r.Groups = groupCommits(commits, cfg.Group)
+	}
+	return r
+}
+
+// groupCommits nests commits by each of the grouping keys in turn
+func groupCommits(commits []Commit, keys []string) []Group {
+	kind := keys[0]
+	byKey := map[string]*Group{}
+	var order []string
+	for _, c := range commits {
+		key, title := groupKey(kind, c)
+		g, ok := byKey[key]
+		if !ok {
+			g = &Group{Kind: kind, Key: key, Title: title}
+			byKey[key] = g
+			order = append(order, key)
+		}
+		g.Commits = append(g.Commits, c)
+		g.Stats.add(c.stats())
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if kind == groupType {
+			return typeRank(order[i]) < typeRank(order[j])
+		}
+		return order[i] < order[j]
+	})
+	groups := make([]Group, len(order))
+	for i, key := range order {
+		g := *byKey[key]
+		if len(keys) > 1 {
+			g.Groups = groupCommits(g.Commits, keys[1:])
+			g.Commits = nil
+		}
+		groups[i] = g
+	}
+	return groups
+}
+
+func groupKey(kind string, c Commit) (string, string) {
+	switch kind {
+	case groupRepo:
+		return c.Repo, c.Repo
+	case groupDay:
+		return c.Date.Format("2006-01-02"), c.Date.Format("2006-01-02 (Monday)")
+	}
+	title, ok := typeTitles[c.Type]
+	if !ok {
+		title = strings.ToUpper(c.Type[:1]) + c.Type[1:]
+	}
+	return c.Type, title
+}
+
+// typeRank orders Conventional Commit types: the common ones first, unknown
+// types next, then "other"
+func typeRank(t string) int {
+	if t == conventional.Other {
+		return len(conventional.TypeOrder) + 1
+	}
+	for i, known := range conventional.TypeOrder {
+		if known == t {
+			return i
+		}
+	}
+	return len(conventional.TypeOrder)
+}
+
+// RangeTitle describes the dates covered, e.g. "2024-05-06" or "2024-05-01 to 2024-05-06"
+func (r Report) RangeTitle() string {
+	from := r.Since.Format("2006-01-02")
+	// An until at midnight ends the day before
+	to := r.Until.Add(-time.Nanosecond).Format("2006-01-02")
+	if from == to {
+		return from
+	}
+	return from + " to " + to
+}
+
+// Summary counts the commits, repositories and changes, naming the author
+func (r Report) Summary() string {
+	repos := ""
+	if len(r.Repos) > 1 {
+		repos = fmt.Sprintf(" in %d repositories", len(r.Repos))
+	}
+	return fmt.Sprintf("%s%s by %s, %s", plural(r.Stats.Commits, "commit"), repos, r.Author, statsText(r.Stats))
+}
+
+func statsText(s Stats) string {
+	return fmt.Sprintf("%s changed, +%d -%d", plural(s.Files, "file"), s.Insertions, s.Deletions)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// commitLine formats a commit for the Markdown and text outputs, leaving out
+// what the surrounding groups already say
+func commitLine(c Commit, markdown bool) string {
+	var parts []string
+	if grouped(groupDay) {
+		parts = append(parts, c.Date.Format("15:04"))
+	} else {
+		parts = append(parts, c.Date.Format("2006-01-02 15:04"))
+	}
+	if markdown {
+		parts = append(parts, "`"+c.Short+"`")
+	} else {
+		parts = append(parts, c.Short)
+	}
+
+	subject := c.Subject
+	if grouped(groupType) && c.Type != "other" {
+		subject = c.Description
+		if c.Scope != "" {
+			if markdown {
+				subject = "**" + c.Scope + ":** " + subject
+			} else {
+				subject = c.Scope + ": " + subject
+			}
+		}
+		if c.Breaking {
+			subject = "BREAKING: " + subject
+		}
+	}
+	parts = append(parts, subject)
+	if !grouped(groupRepo) && multipleRepos {
+		parts = append(parts, "["+c.Repo+"]")
+	}
+	parts = append(parts, fmt.Sprintf("(+%d -%d)", c.Insertions, c.Deletions))
+	return strings.Join(parts, " ")
+}
+
+func grouped(kind string) bool {
+	for _, g := range cfg.Group {
+		if g == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func renderMarkdown(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "# Commit Diary - %s\n\n", r.RangeTitle())
+	fmt.Fprintf(w, "_%s_\n", r.Summary())
+	for _, c := range r.Commits {
+		fmt.Fprintf(w, "\n- %s", commitLine(c, true))
+	}
+	if len(r.Commits) > 0 {
+		fmt.Fprintln(w)
+	}
+	renderMarkdownGroups(w, r.Groups, 2)
+	return nil
+}
+
+func renderMarkdownGroups(w io.Writer, groups []Group, level int) {
+	for _, g := range groups {
+		fmt.Fprintf(w, "\n%s %s\n\n", strings.Repeat("#", level), g.Title)
+		fmt.Fprintf(w, "_%s, %s_\n", plural(g.Stats.Commits, "commit"), statsText(g.Stats))
+		if len(g.Commits) > 0 {
+			fmt.Fprintln(w)
+			for _, c := range g.Commits {
+				fmt.Fprintf(w, "- %s\n", commitLine(c, true))
+			}
+		}
+		renderMarkdownGroups(w, g.Groups, level+1)
+	}
+}
+
+func renderText(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "Commit diary %s\n%s\n", r.RangeTitle(), r.Summary())
+	if len(r.Commits) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, c := range r.Commits {
+		fmt.Fprintf(w, "%s\n", commitLine(c, false))
+	}
+	renderTextGroups(w, r.Groups, 0)
+	return nil
+}
+
+func renderTextGroups(w io.Writer, groups []Group, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, g := range groups {
+		if depth == 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s%s: %s, %s\n", indent, g.Title, plural(g.Stats.Commits, "commit"), statsText(g.Stats))
+		for _, c := range g.Commits {
+			fmt.Fprintf(w, "%s  %s\n", indent, commitLine(c, false))
+		}
+		renderTextGroups(w, g.Groups, depth+1)
+	}
+}
+
+func renderJSON(w io.Writer, r Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
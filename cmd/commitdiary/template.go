@@ -0,0 +1,249 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/amanmehtacode/GitNoob/internal/conventional"
+	"github.com/spf13/cobra"
+)
+
+// builtinTemplates are the templates shipped with commitdiary
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// templateSince is the default --since of each built-in template
+var templateSince = map[string]string{
+	"standup":   "lastworkday",
+	"weekly":    "6d",
+	"changelog": "4w",
+}
+
+// highlightTypes are the commit types a weekly summary calls out
+var highlightTypes = map[string]bool{"feat": true, "fix": true, "perf": true}
+
+// maxHighlights caps the commits listed as highlights
+const maxHighlights = 5
+
+// TemplateData is what templates are executed with: the report plus the
+// commits cut up the ways the built-in templates need
+type TemplateData struct {
+	Report
+	All        []Commit // every commit, oldest first
+	Today      []Commit
+	Yesterday  []Commit // the last working day, whatever --since reaches back to
+	ByDay      []Group
+	ByRepo     []Group
+	ByType     []Group
+	Highlights []Commit // breaking changes, features, fixes and performance work, largest first
+	Now        time.Time
+	MultiRepo  bool
+}
+
+type diaryTemplate struct {
+	*template.Template
+}
+
+// templateFuncs are the functions available in templates besides the built-ins
+var templateFuncs = template.FuncMap{
+	"date":   func(layout string, t time.Time) string { return t.Format(layout) },
+	"plural": plural,
+	"stats":  statsText,
+	"join":   strings.Join,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+	"issues": func(commits []Commit) []string {
+		var all []string
+		for _, c := range commits {
+			all = append(all, c.Issues...)
+		}
+		return conventional.FindIssues(strings.Join(all, " "))
+	},
+	"limit": func(n int, commits []Commit) []Commit {
+		if len(commits) > n {
+			return commits[:n]
+		}
+		return commits
+	},
+	"breaking": func(commits []Commit) []Commit {
+		var found []Commit
+		for _, c := range commits {
+			if c.Breaking {
+				found = append(found, c)
+			}
+		}
+		return found
+	},
+}
+
+// templatesDir is where user templates live, overriding built-ins of the same name
+func templatesDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitnoob", "commitdiary"), nil
+}
+
+// loadTemplate finds a template by path, then by name in the templates
+// directory, then among the built-ins
+func loadTemplate(name string) (diaryTemplate, error) {
+	var text []byte
+	var err error
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, ".tmpl") {
+		text, err = os.ReadFile(name)
+		if err != nil {
+			return diaryTemplate{}, fmt.Errorf("failed to read template: %v", err)
+		}
+	} else {
+		if dir, dirErr := templatesDir(); dirErr == nil {
+			text, err = os.ReadFile(filepath.Join(dir, name+".tmpl"))
+		}
+		if text == nil {
+			text, err = builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+		}
+		if err != nil {
+			return diaryTemplate{}, fmt.Errorf("unknown template %q; run commitdiary templates to list them", name)
+		}
+	}
+
+	tmpl, err := template.New(filepath.Base(name)).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return diaryTemplate{}, fmt.Errorf("invalid template: %v", err)
+	}
+	return diaryTemplate{tmpl}, nil
+}
+
+func (t diaryTemplate) render(w io.Writer, r Report) error {
+	return t.Execute(w, newTemplateData(r))
+}
+
+func newTemplateData(r Report) TemplateData {
+	all := flatten(r.Commits, r.Groups)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Date.Before(all[j].Date) })
+
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	lastWorkday, _ := parseDate("lastworkday", false)
+	d := TemplateData{
+		Report:    r,
+		All:       all,
+		ByDay:     groupCommits(all, []string{groupDay}),
+		ByRepo:    groupCommits(all, []string{groupRepo}),
+		ByType:    groupCommits(all, []string{groupType}),
+		Now:       now,
+		MultiRepo: len(r.Repos) > 1,
+	}
+	for _, c := range all {
+		switch {
+		case !c.Date.Before(midnight):
+			d.Today = append(d.Today, c)
+		case !c.Date.Before(lastWorkday):
+			d.Yesterday = append(d.Yesterday, c)
+		}
+		if c.Breaking || highlightTypes[c.Type] {
+			d.Highlights = append(d.Highlights, c)
+		}
+	}
+	sort.SliceStable(d.Highlights, func(i, j int) bool {
+		a, b := d.Highlights[i], d.Highlights[j]
+		if a.Breaking != b.Breaking {
+			return a.Breaking
+		}
+		return a.Insertions+a.Deletions > b.Insertions+b.Deletions
+	})
+	if len(d.Highlights) > maxHighlights {
+		d.Highlights = d.Highlights[:maxHighlights]
+	}
+	return d
+}
+
+// flatten collects the commits of a report whether or not they were grouped
+func flatten(commits []Commit, groups []Group) []Commit {
+	all := append([]Commit(nil), commits...)
+	for _, g := range groups {
+		all = append(all, flatten(g.Commits, g.Groups)...)
+	}
+	return all
+}
+
+// newTemplatesCmd lists the available templates and can copy the built-ins
+// into the templates directory to edit them
+func newTemplatesCmd() *cobra.Command {
+	var export bool
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "List the diary templates, or copy the built-in ones to edit them",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := templatesDir()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, red(fmt.Sprintf("Cannot find the templates directory: %v", err)))
+				os.Exit(1)
+			}
+			builtins, _ := fs.Glob(builtinTemplates, "templates/*.tmpl")
+
+			if export {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					fmt.Fprintln(os.Stderr, red(fmt.Sprintf("Failed to create %s: %v", dir, err)))
+					os.Exit(1)
+				}
+				for _, path := range builtins {
+					target := filepath.Join(dir, filepath.Base(path))
+					if _, err := os.Stat(target); err == nil {
+						fmt.Println(yellow(fmt.Sprintf("Keeping %s, it already exists", target)))
+						continue
+					}
+					text, _ := builtinTemplates.ReadFile(path)
+					if err := os.WriteFile(target, text, 0644); err != nil {
+						fmt.Fprintln(os.Stderr, red(fmt.Sprintf("Failed to write %s: %v", target, err)))
+						os.Exit(1)
+					}
+					fmt.Println(green(fmt.Sprintf("✓ Wrote %s", target)))
+				}
+				return
+			}
+
+			user := map[string]bool{}
+			userFiles, _ := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+			for _, path := range userFiles {
+				user[strings.TrimSuffix(filepath.Base(path), ".tmpl")] = true
+			}
+			fmt.Printf("Templates directory: %s\n\n", dir)
+			for _, path := range builtins {
+				name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+				note := "built-in"
+				if user[name] {
+					note = "customized"
+					delete(user, name)
+				}
+				fmt.Printf("  %-12s %s, default --since %s\n", name, note, templateSince[name])
+			}
+			var names []string
+			for name := range user {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("  %-12s user template\n", name)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&export, "export", false, "Copy the built-in templates into the templates directory, keeping existing files")
+	return cmd
+}
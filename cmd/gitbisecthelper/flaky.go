@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// testRun is the outcome of one run of the test command
+type testRun struct {
+	Code     int
+	Duration time.Duration
+}
+
+// step is everything learned about one commit
+type step struct {
+	Commit   string
+	Subject  string
+	Runs     []testRun
+	Failures int
+	Skips    int
+	Verdict  string
+	Duration time.Duration
+	Note     string
+}
+
+// failureRate is the share of failed runs among those that were not skipped
+func (s step) failureRate() float64 {
+	counted := len(s.Runs) - s.Skips
+	if counted == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(counted)
+}
+
+// runTest runs the test command up to --repeat times and classifies the
+// commit. An empty verdict means the search was interrupted.
+func runTest() (string, error) {
+	summary, _ := gitOutput("log", "-1", "--format=%h %s")
+	if cfg.Repeat > 1 {
+		summary += fmt.Sprintf(" (%d runs)", cfg.Repeat)
+	}
+	fmt.Printf("%s Testing %s\n", yellow("→"), summary)
+
+	var s step
+	start := time.Now()
+	for i := 0; i < cfg.Repeat; i++ {
+		run, err := runOnce()
+		if interrupted.Load() {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		s.Runs = append(s.Runs, run)
+		switch {
+		case run.Code == 125:
+			s.Skips++
+		case run.Code != 0:
+			s.Failures++
+		}
+		logVerbose(fmt.Sprintf("run %d: exit code %d in %s", i+1, run.Code, run.Duration.Round(time.Millisecond)))
+
+		// Enough failures to call it bad whatever the remaining runs do
+		if s.Failures > 0 && float64(s.Failures)/float64(cfg.Repeat) >= cfg.Threshold {
+			break
+		}
+	}
+	s.Duration = time.Since(start)
+	s.Verdict = classify(s)
+
+	detail := fmt.Sprintf("%d/%d run(s) failed, %.0f%%", s.Failures, len(s.Runs), s.failureRate()*100)
+	if s.Skips > 0 {
+		detail += fmt.Sprintf(", %d skipped", s.Skips)
+	}
+	detail += fmt.Sprintf(", %s", s.Duration.Round(time.Millisecond))
+	switch s.Verdict {
+	case verdictGood:
+		fmt.Println(green("  good") + " (" + detail + ")")
+	case verdictBad:
+		fmt.Println(red("  bad") + " (" + detail + ")")
+	default:
+		fmt.Println(yellow("  skip") + " (" + detail + ")")
+	}
+	reportStep(s)
+	return s.Verdict, nil
+}
+
+// classify turns run results into a verdict: skipped when every run asked to
+// skip, otherwise bad on any failure or, with --threshold, at that failure rate
+func classify(s step) string {
+	switch {
+	case len(s.Runs) == s.Skips:
+		return verdictSkip
+	case s.Failures == 0:
+		return verdictGood
+	case cfg.Threshold == 0 || s.failureRate() >= cfg.Threshold:
+		return verdictBad
+	}
+	return verdictGood
+}
+
+// runOnce runs the test command, following git bisect run's exit code rules:
+// 0 is good, 125 is skip, 1-127 is bad and anything else stops the search
+func runOnce() (testRun, error) {
+	test := exec.Command("sh", "-c", cfg.Run)
+	if cfg.VerboseMode {
+		test.Stdout, test.Stderr = os.Stdout, os.Stderr
+	}
+	start := time.Now()
+	err := test.Run()
+	run := testRun{Duration: time.Since(start)}
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return run, err
+		}
+		run.Code = exitErr.ExitCode()
+	}
+	if run.Code < 0 || run.Code >= 128 {
+		return run, fmt.Errorf("test command exited with code %d", run.Code)
+	}
+	return run, nil
+}
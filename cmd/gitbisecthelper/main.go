@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Good        string
+	Bad         string
+	Run         string
+	Repeat      int
+	Threshold   float64
+	Report      string
+	Resume      bool
+	Abort       bool
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+
+	// interrupted is set by Ctrl+C so a half-run test is not recorded
+	interrupted atomic.Bool
+)
+
+// Verdicts for a tested commit, named after the git bisect subcommands
+const (
+	verdictGood = "good"
+	verdictBad  = "bad"
+	verdictSkip = "skip"
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "gitbisecthelper",
+		Short: "Find the commit that introduced a bug with a guided git bisect",
+		Long: `Find the commit that introduced a bug.
+
+With --run, the command is run at every step: exit code 0 marks the commit
+good, 125 skips it, and any other code up to 127 marks it bad. Without --run
+you are asked whether each commit is good or bad.
+
+For flaky tests, --repeat runs the command several times per commit. A
+commit is bad if any run fails, or with --threshold if at least that share
+of the runs fail. Every step is logged with its timings to a report file.
+
+Without --good, tags reachable from the bad commit are tried newest first
+until a good one is found. The original HEAD is always checked out again
+when the search ends or is interrupted; an interrupted search is saved and
+continues with --resume.`,
+		Args: cobra.NoArgs,
+		Run:  bisect,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().StringVarP(&cfg.Good, "good", "g", "", "A commit known to work (default: the newest good tag)")
+	rootCmd.Flags().StringVarP(&cfg.Bad, "bad", "b", "HEAD", "A commit known to be broken")
+	rootCmd.Flags().StringVarP(&cfg.Run, "run", "r", "", "Test command run at each step (through sh -c)")
+	rootCmd.Flags().IntVarP(&cfg.Repeat, "repeat", "n", 1, "Run the test command this many times per commit")
+	rootCmd.Flags().Float64Var(&cfg.Threshold, "threshold", 0, "Failure rate (0-1) at which a commit counts as bad; 0 means any failure")
+	rootCmd.Flags().StringVar(&cfg.Report, "report", "", "Report file for per-step results (default .git/gitnoob/bisect-report.md)")
+	rootCmd.Flags().BoolVar(&cfg.Resume, "resume", false, "Continue a saved or interrupted search")
+	rootCmd.Flags().BoolVar(&cfg.Abort, "abort", false, "Forget a saved search and restore the original HEAD")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.MarkFlagsMutuallyExclusive("resume", "abort")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func bisect(cmd *cobra.Command, args []string) {
+	if cfg.Abort {
+		abortSession()
+		return
+	}
+	if cfg.Repeat < 1 || cfg.Threshold < 0 || cfg.Threshold > 1 {
+		log.Fatal(red("--repeat must be at least 1 and --threshold between 0 and 1"))
+	}
+	if dirty, _ := gitOutput("status", "--porcelain", "--untracked-files=no"); dirty != "" {
+		log.Fatal(red("You have uncommitted changes. Commit or stash them before bisecting."))
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		interrupted.Store(true)
+	}()
+
+	if err := search(cmd); err != nil {
+		// Never exit with git bisect still active: save the search so it can
+		// be resumed, and check out the original HEAD again
+		if bisecting() {
+			suspendSession()
+		}
+		log.Fatal(red(err.Error()))
+	}
+}
+
+// search starts, resumes or continues a search and runs it to the end or
+// until the user stops; errors are returned for bisect to clean up after
+func search(cmd *cobra.Command) error {
+	active := bisecting()
+	if active || cfg.Resume {
+		restoreSettings(cmd.Flags().Changed)
+	}
+	openReport(!active && !cfg.Resume)
+	defer closeReport()
+
+	var output string
+	var err error
+	switch {
+	case active:
+		fmt.Println(yellow("→ Continuing the bisect in progress..."))
+	case cfg.Resume:
+		output, err = resumeSession()
+	default:
+		output, err = startSession()
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		if done := reportIfFinished(output); done {
+			finishSession()
+			return nil
+		}
+		if line := progressLine(output); line != "" {
+			fmt.Println(yellow("→ " + line))
+		}
+
+		verdict, quit := testCurrent()
+		if quit || interrupted.Load() {
+			suspendSession()
+			return nil
+		}
+
+		output, err = gitOutput("bisect", verdict)
+		logVerbose(output)
+		// git bisect skip exits non-zero when only skipped commits are left,
+		// which is a result rather than a failure
+		if err != nil {
+			if reportIfFinished(output) {
+				finishSession()
+				return nil
+			}
+			return fmt.Errorf("git bisect %s failed: %v", verdict, err)
+		}
+	}
+}
+
+// startSession begins a new search between the good and bad commits
+func startSession() (string, error) {
+	bad, err := gitOutput("rev-parse", "--verify", cfg.Bad+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("Unknown bad commit %s", cfg.Bad)
+	}
+	good := cfg.Good
+	if good == "" {
+		if good, err = findGoodTag(bad); err != nil {
+			return "", err
+		}
+	}
+	if _, err := gitOutput("rev-parse", "--verify", good+"^{commit}"); err != nil {
+		return "", fmt.Errorf("Unknown good commit %s", good)
+	}
+
+	fmt.Println(yellow(fmt.Sprintf("→ Bisecting between good %s and bad %s...", good, cfg.Bad)))
+	output, err := gitOutput("bisect", "start", bad, good)
+	if err != nil {
+		gitOutput("bisect", "reset")
+		return "", fmt.Errorf("Failed to start bisecting: %v", err)
+	}
+	saveSession()
+	return output, nil
+}
+
+// findGoodTag walks back through the tags reachable from bad, newest first,
+// testing each until one is good
+func findGoodTag(bad string) (string, error) {
+	output, _ := gitOutput("tag", "--merged", bad, "--sort=-creatordate")
+	tags := nonEmptyLines(output)
+	if len(tags) == 0 {
+		return "", fmt.Errorf("No tags to start from. Pass a known good commit with --good.")
+	}
+
+	original := currentHead()
+	defer checkout(original)
+
+	for _, tag := range tags {
+		commit, _ := gitOutput("rev-parse", tag+"^{commit}")
+		if commit == bad {
+			continue
+		}
+		fmt.Println(yellow(fmt.Sprintf("→ Looking for a good starting point: trying %s...", tag)))
+		if _, err := gitOutput("checkout", "--quiet", "--detach", tag); err != nil {
+			return "", fmt.Errorf("Failed to check out %s: %v", tag, err)
+		}
+		verdict, quit := testCurrent()
+		if quit || interrupted.Load() {
+			checkout(original)
+			os.Exit(1)
+		}
+		if verdict == verdictGood {
+			fmt.Println(green(fmt.Sprintf("✓ %s is good", tag)))
+			return tag, nil
+		}
+	}
+	return "", fmt.Errorf("Every tag is bad or untestable. Pass a known good commit with --good.")
+}
+
+// testCurrent decides whether the checked-out commit is good, bad or skipped;
+// quit is true when the user wants to stop for now
+func testCurrent() (string, bool) {
+	if cfg.Run == "" {
+		return askVerdict()
+	}
+	verdict, err := runTest()
+	if err != nil {
+		logError("Test command could not be run", err)
+		return "", true
+	}
+	return verdict, false
+}
+
+// askVerdict asks the user to judge the checked-out commit
+func askVerdict() (string, bool) {
+	summary, _ := gitOutput("log", "-1", "--format=%h %s (%an, %ar)")
+	options := []string{"Good", "Bad", "Skip (cannot test this one)", "Quit and resume later", "Abort"}
+	var answer int
+	if err := survey.AskOne(&survey.Select{Message: "Is " + summary + " good or bad?", Options: options}, &answer); err != nil {
+		return "", true
+	}
+	verdicts := []string{verdictGood, verdictBad, verdictSkip}
+	if answer < len(verdicts) {
+		reportStep(step{Verdict: verdicts[answer], Note: "judged by hand"})
+		return verdicts[answer], false
+	}
+	switch answer {
+	case 4:
+		abortSession()
+		os.Exit(0)
+	}
+	return "", true
+}
+
+var (
+	culpritPattern  = regexp.MustCompile(`(?m)^([0-9a-f]{40}) is the first bad commit`)
+	progressPattern = regexp.MustCompile(`(?m)^Bisecting: .*$`)
+)
+
+// reportIfFinished prints the result once git bisect has narrowed it down
+func reportIfFinished(output string) bool {
+	if match := culpritPattern.FindStringSubmatch(output); match != nil {
+		printCulprit(match[1])
+		summary, _ := gitOutput("log", "-1", "--format=%h %s (%an)", match[1])
+		reportOutcome("First bad commit: " + summary)
+		return true
+	}
+	if strings.Contains(output, "only 'skip'ped commits left") {
+		fmt.Println(yellow("Only skipped commits are left. The first bad commit is one of:"))
+		candidates, _ := gitOutput("bisect", "visualize", "--oneline", "--no-decorate")
+		for _, line := range nonEmptyLines(candidates) {
+			fmt.Printf("  %s\n", line)
+		}
+		reportOutcome("Only skipped commits left: " + strings.Join(nonEmptyLines(candidates), "; "))
+		return true
+	}
+	return false
+}
+
+// progressLine returns the last "Bisecting: N revisions left" line; a replay prints several
+func progressLine(output string) string {
+	lines := progressPattern.FindAllString(output, -1)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[len(lines)-1]
+}
+
+// printCulprit shows the first bad commit with its author and diff stat
+func printCulprit(commit string) {
+	fmt.Println()
+	fmt.Println(red("✗ First bad commit:"))
+	details, _ := gitOutput("show", "--stat", "--format=%H%nAuthor: %an <%ae>%nDate:   %ad%n%n    %s", commit)
+	for _, line := range strings.Split(details, "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+}
+
+// currentHead returns the checked-out branch, or the commit when detached
+func currentHead() string {
+	if branch, err := gitOutput("symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		return branch
+	}
+	head, _ := gitOutput("rev-parse", "HEAD")
+	return head
+}
+
+func checkout(ref string) {
+	if _, err := gitOutput("checkout", "--quiet", ref); err != nil {
+		logError(fmt.Sprintf("Failed to check out %s again", ref), err)
+	}
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// gitOutput runs a git command and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode && message != "" {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
+
+func logError(message string, err error) {
+	log.Printf("%s %s: %v", red("✗"), message, err)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// report is the Markdown log of every tested commit, appended to when a
+// search is resumed
+var report *os.File
+
+// reportPath returns --report or .git/gitnoob/bisect-report.md
+func reportPath() string {
+	if cfg.Report != "" {
+		return cfg.Report
+	}
+	return filepath.Join(filepath.Dir(sessionPath()), "bisect-report.md")
+}
+
+// openReport starts a new report, or continues the existing one on resume
+func openReport(fresh bool) {
+	path := reportPath()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if fresh {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logError("Failed to create the report", err)
+		return
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		logError("Failed to create the report", err)
+		return
+	}
+	report = f
+
+	heading := "Resumed"
+	if fresh {
+		heading = "# Bisect report\n\nStarted"
+	}
+	test := "manual good/bad answers"
+	if cfg.Run != "" {
+		test = fmt.Sprintf("`%s`, %d run(s) per commit, ", cfg.Run, cfg.Repeat)
+		if cfg.Threshold == 0 {
+			test += "bad on any failure"
+		} else {
+			test += fmt.Sprintf("bad at %.0f%% failures", cfg.Threshold*100)
+		}
+	}
+	fmt.Fprintf(report, "\n%s %s. Test: %s\n\n", heading, time.Now().Format("2006-01-02 15:04:05"), test)
+	fmt.Fprintln(report, "| Time | Commit | Subject | Runs | Failed | Skipped | Fail rate | Duration | Verdict |")
+	fmt.Fprintln(report, "|------|--------|---------|------|--------|---------|-----------|----------|---------|")
+}
+
+// reportStep appends one tested commit to the report
+func reportStep(s step) {
+	if report == nil {
+		return
+	}
+	if s.Commit == "" {
+		s.Commit, _ = gitOutput("rev-parse", "--short", "HEAD")
+		s.Subject, _ = gitOutput("log", "-1", "--format=%s")
+	}
+
+	runs := make([]string, len(s.Runs))
+	for i, r := range s.Runs {
+		mark := "✓"
+		switch {
+		case r.Code == 125:
+			mark = "–"
+		case r.Code != 0:
+			mark = "✗"
+		}
+		runs[i] = fmt.Sprintf("%s%s", mark, r.Duration.Round(time.Millisecond))
+	}
+	runCell := strings.Join(runs, " ")
+	if s.Note != "" {
+		runCell = s.Note
+	}
+
+	subject := strings.ReplaceAll(s.Subject, "|", "\\|")
+	fmt.Fprintf(report, "| %s | %s | %s | %s | %d | %d | %.0f%% | %s | %s |\n",
+		time.Now().Format("15:04:05"), s.Commit, subject, runCell, s.Failures, s.Skips,
+		s.failureRate()*100, s.Duration.Round(time.Millisecond), s.Verdict)
+}
+
+// reportOutcome records how the search ended
+func reportOutcome(outcome string) {
+	if report == nil {
+		return
+	}
+	fmt.Fprintf(report, "\n**Result:** %s\n", outcome)
+}
+
+func closeReport() {
+	if report == nil {
+		return
+	}
+	report.Close()
+	fmt.Printf("%s Report written to %s\n", yellow("→"), reportPath())
+	report = nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// session is a search saved between runs. git bisect keeps its own state
+// while a search is active; the saved log lets a search continue after the
+// original HEAD has been restored.
+type session struct {
+	Run       string  `json:"run,omitempty"`
+	Repeat    int     `json:"repeat,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Report    string  `json:"report,omitempty"`
+	Log       string  `json:"log,omitempty"`
+}
+
+// sessionPath returns .git/gitnoob/bisect.json, shared by all worktrees
+func sessionPath() string {
+	dir, err := gitOutput("rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		log.Fatal(red("Not inside a Git repository"))
+	}
+	return filepath.Join(dir, "gitnoob", "bisect.json")
+}
+
+func loadSession() (session, bool) {
+	var s session
+	content, err := os.ReadFile(sessionPath())
+	if err != nil {
+		return s, false
+	}
+	if err := json.Unmarshal(content, &s); err != nil {
+		return s, false
+	}
+	return s, true
+}
+
+func writeSession(s session) {
+	path := sessionPath()
+	content, _ := json.MarshalIndent(s, "", "  ")
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = os.WriteFile(path, content, 0644)
+	}
+	if err != nil {
+		logError("Failed to save the search", err)
+	}
+}
+
+// currentSession captures the test settings, plus the bisect log when suspending
+func currentSession(bisectLog string) session {
+	return session{Run: cfg.Run, Repeat: cfg.Repeat, Threshold: cfg.Threshold, Report: cfg.Report, Log: bisectLog}
+}
+
+// saveSession remembers the test settings of a new search
+func saveSession() {
+	writeSession(currentSession(""))
+}
+
+// bisecting reports whether git has a search in progress
+func bisecting() bool {
+	_, err := gitOutput("bisect", "log")
+	return err == nil
+}
+
+// restoreSettings picks up the test settings a search was started with,
+// unless they were given again on the command line
+func restoreSettings(changed func(flag string) bool) {
+	s, ok := loadSession()
+	if !ok {
+		return
+	}
+	if !changed("run") {
+		cfg.Run = s.Run
+	}
+	if !changed("repeat") && s.Repeat > 0 {
+		cfg.Repeat = s.Repeat
+	}
+	if !changed("threshold") {
+		cfg.Threshold = s.Threshold
+	}
+	if !changed("report") {
+		cfg.Report = s.Report
+	}
+}
+
+// suspendSession saves the search and checks out the original HEAD again
+func suspendSession() {
+	bisectLog, err := gitOutput("bisect", "log")
+	if err != nil {
+		logError("Failed to save the search", err)
+	}
+	writeSession(currentSession(bisectLog))
+	if _, err := gitOutput("bisect", "reset"); err != nil {
+		logError("Failed to restore the original HEAD", err)
+	}
+	fmt.Println(yellow("Search saved and original HEAD restored. Continue with: gitbisecthelper --resume"))
+}
+
+// resumeSession replays a saved search
+func resumeSession() (string, error) {
+	s, ok := loadSession()
+	if !ok || s.Log == "" {
+		return "", fmt.Errorf("No saved search to resume.")
+	}
+
+	replay, err := os.CreateTemp("", "gitbisecthelper-*.log")
+	if err != nil {
+		return "", fmt.Errorf("Failed to replay the search: %v", err)
+	}
+	defer os.Remove(replay.Name())
+	replay.WriteString(s.Log + "\n")
+	replay.Close()
+
+	fmt.Println(yellow("→ Resuming the saved search..."))
+	output, err := gitOutput("bisect", "replay", replay.Name())
+	if err != nil {
+		// Reset here rather than suspend, which would save over the log
+		gitOutput("bisect", "reset")
+		return "", fmt.Errorf("Failed to replay the search: %v", err)
+	}
+	return output, nil
+}
+
+// finishSession ends the search and checks out the original HEAD again
+func finishSession() {
+	if _, err := gitOutput("bisect", "reset"); err != nil {
+		logError("Failed to restore the original HEAD", err)
+	}
+	os.Remove(sessionPath())
+	fmt.Println(green(fmt.Sprintf("✓ Original HEAD restored (%s).", currentHead())))
+}
+
+// abortSession throws away a saved or active search
+func abortSession() {
+	if _, err := gitOutput("bisect", "log"); err == nil {
+		if _, err := gitOutput("bisect", "reset"); err != nil {
+			logError("Failed to restore the original HEAD", err)
+		}
+	}
+	os.Remove(sessionPath())
+	fmt.Println(green(fmt.Sprintf("✓ Search aborted, original HEAD restored (%s).", currentHead())))
+}
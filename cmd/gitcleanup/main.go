@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/amanmehtacode/GitNoob/internal/squash"
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	StaleDays   int
+	Remote      string
+	NoFetch     bool
+	DryRun      bool
+	Interactive bool
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	s   *spinner.Spinner
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+// defaultProtected are never offered for deletion, in addition to the
+// patterns configured with: git config --add gitcleanup.protected 'release/*'
+var defaultProtected = []string{"main", "master", "develop"}
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "gitcleanup",
+		Short: "Find and delete merged, squash-merged, orphaned and stale branches",
+		Run:   gitCleanup,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().IntVarP(&cfg.StaleDays, "days", "d", 90, "Treat branches without commits for this many days as stale (0 to disable)")
+	rootCmd.Flags().StringVarP(&cfg.Remote, "remote", "r", "origin", "Remote whose branches are checked and deleted")
+	rootCmd.Flags().BoolVar(&cfg.NoFetch, "no-fetch", false, "Do not fetch and prune the remote first")
+	rootCmd.Flags().BoolVarP(&cfg.DryRun, "dry-run", "n", false, "Only list the branches that would be offered for deletion")
+	rootCmd.Flags().BoolVarP(&cfg.Interactive, "interactive", "i", true, "Choose branches in a multi-select; otherwise delete every merged or orphaned branch")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+// candidate is a branch that may be deleted and why
+type candidate struct {
+	Name     string
+	Upstream string // remote branch the local branch tracks, if it still exists
+	Reasons  []string
+	Merged   bool // fully merged into the base
+	Landed   bool // merged, squash-merged or upstream gone, selected by default
+}
+
+func (c candidate) label() string {
+	return fmt.Sprintf("%s (%s)", c.Name, strings.Join(c.Reasons, ", "))
+}
+
+func gitCleanup(cmd *cobra.Command, args []string) {
+	if !cfg.NoFetch && hasRemote(cfg.Remote) {
+		startSpinner(fmt.Sprintf("Fetching and pruning %s", cfg.Remote))
+		err := runCommand("git", "fetch", "--prune", cfg.Remote)
+		stopSpinner()
+		if err != nil {
+			log.Printf(yellow("Could not fetch %s, results may be out of date: %v"), cfg.Remote, err)
+		}
+	}
+
+	base, err := defaultBranch()
+	if err != nil {
+		log.Fatalf(red("Cannot determine the default branch: %v"), err)
+	}
+	fmt.Println(yellow(fmt.Sprintf("→ Comparing branches against %s", base)))
+
+	protected := protectedPatterns(base)
+	locals := localCandidates(base, protected)
+	remotes := remoteCandidates(base, protected, locals)
+
+	if len(locals) == 0 && len(remotes) == 0 {
+		fmt.Println(green("✓ Nothing to clean up. 🎉"))
+		return
+	}
+
+	selectedLocals := choose("Select local branches to delete:", locals)
+	selectedRemotes := choose(fmt.Sprintf("Select %s branches to delete:", cfg.Remote), remotes)
+
+	// Offer to delete the remote counterparts of local branches being removed
+	var tracked []candidate
+	for _, c := range selectedLocals {
+		if c.Upstream != "" && !contains(selectedRemotes, c.Upstream) {
+			tracked = append(tracked, candidate{Name: c.Upstream, Reasons: []string{"tracked by " + c.Name}, Landed: c.Landed})
+		}
+	}
+	selectedRemotes = append(selectedRemotes, choose(fmt.Sprintf("Also delete these branches on %s?", cfg.Remote), tracked)...)
+
+	if cfg.DryRun {
+		return
+	}
+
+	failures := 0
+	for _, c := range selectedLocals {
+		// git branch -d checks against HEAD, not the base, so check the base
+		// here and force the deletion
+		if c.Merged && !isAncestor(c.Name, base) {
+			logError(fmt.Sprintf("Failed to delete local branch %s", c.Name), fmt.Errorf("it is no longer merged into %s", base))
+			failures++
+			continue
+		}
+		if err := runCommand("git", "branch", "-D", c.Name); err != nil {
+			logError(fmt.Sprintf("Failed to delete local branch %s", c.Name), err)
+			failures++
+			continue
+		}
+		fmt.Println(green(fmt.Sprintf("✓ Deleted local branch %s", c.Name)))
+	}
+	for _, c := range selectedRemotes {
+		name := strings.TrimPrefix(c.Name, cfg.Remote+"/")
+		if err := runCommand("git", "push", cfg.Remote, "--delete", name); err != nil {
+			logError(fmt.Sprintf("Failed to delete remote branch %s", c.Name), err)
+			failures++
+			continue
+		}
+		fmt.Println(green(fmt.Sprintf("✓ Deleted remote branch %s", c.Name)))
+	}
+
+	if failures > 0 {
+		log.Fatalf(red("%d branch(es) could not be deleted"), failures)
+	}
+	fmt.Println(green("✓ Git cleanup complete. 🧹"))
+}
+
+// localCandidates classifies every unprotected local branch
+func localCandidates(base string, protected []string) []candidate {
+	current := strings.TrimSpace(gitOutput("branch", "--show-current"))
+	format := "%(refname:short)%00%(upstream:short)%00%(upstream:track)%00%(committerdate:unix)"
+	var candidates []candidate
+
+	for _, line := range nonEmptyLines(gitOutput("for-each-ref", "--format="+format, "refs/heads")) {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		name, upstream, track := fields[0], fields[1], fields[2]
+		if name == current || isProtected(name, protected) {
+			continue
+		}
+
+		c := candidate{Name: name}
+		if upstream != "" && track != "[gone]" && strings.HasPrefix(upstream, cfg.Remote+"/") {
+			c.Upstream = upstream
+		}
+		switch {
+		case isAncestor(name, base):
+			c.Reasons = append(c.Reasons, "merged into "+base)
+			c.Merged, c.Landed = true, true
+		case squash.Merged(name, base):
+			c.Reasons = append(c.Reasons, "squash-merged into "+base)
+			c.Landed = true
+		}
+		if track == "[gone]" {
+			c.Reasons = append(c.Reasons, "upstream "+upstream+" is gone")
+			c.Landed = true
+		}
+		if days := staleDays(fields[3]); days > 0 {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("untouched for %d days", days))
+		}
+
+		if len(c.Reasons) > 0 {
+			candidates = append(candidates, c)
+			logVerbose(c.label())
+		}
+	}
+	return candidates
+}
+
+// remoteCandidates finds merged remote branches that no local branch tracks
+func remoteCandidates(base string, protected []string, locals []candidate) []candidate {
+	tracked := map[string]bool{}
+	for _, line := range nonEmptyLines(gitOutput("for-each-ref", "--format=%(upstream:short)", "refs/heads")) {
+		tracked[line] = true
+	}
+	for _, c := range locals {
+		// Offered separately once the local branch is chosen
+		delete(tracked, c.Upstream)
+	}
+
+	var candidates []candidate
+	format := "%(refname:short)%00%(committerdate:unix)"
+	for _, line := range nonEmptyLines(gitOutput("for-each-ref", "--format="+format, "refs/remotes/"+cfg.Remote)) {
+		fields := strings.Split(line, "\x00")
+		name := fields[0]
+		short := strings.TrimPrefix(name, cfg.Remote+"/")
+		if name == base || short == "HEAD" || name == cfg.Remote || isProtected(short, protected) || tracked[name] {
+			continue
+		}
+		if hasLocalTracking(locals, name) {
+			continue
+		}
+
+		c := candidate{Name: name}
+		switch {
+		case isAncestor(name, base):
+			c.Reasons = append(c.Reasons, "merged into "+base)
+			c.Merged, c.Landed = true, true
+		case squash.Merged(name, base):
+			c.Reasons = append(c.Reasons, "squash-merged into "+base)
+			c.Landed = true
+		default:
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+func hasLocalTracking(locals []candidate, remoteBranch string) bool {
+	for _, c := range locals {
+		if c.Upstream == remoteBranch {
+			return true
+		}
+	}
+	return false
+}
+
+// choose presents candidates in a multi-select, pre-selecting merged and
+// orphaned branches; stale-only branches must be picked explicitly
+func choose(message string, candidates []candidate) []candidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if cfg.DryRun || !cfg.Interactive {
+		fmt.Println(yellow(message))
+		var selected []candidate
+		for _, c := range candidates {
+			marker := "  "
+			if c.Landed {
+				marker = "✓ "
+				selected = append(selected, c)
+			}
+			fmt.Printf("  %s%s\n", marker, c.label())
+		}
+		return selected
+	}
+
+	options := make([]string, len(candidates))
+	var defaults []string
+	for i, c := range candidates {
+		options[i] = c.label()
+		if c.Landed {
+			defaults = append(defaults, options[i])
+		}
+	}
+
+	var picked []string
+	prompt := &survey.MultiSelect{Message: message, Options: options, Default: defaults, PageSize: 15}
+	if err := survey.AskOne(prompt, &picked); err != nil {
+		log.Fatalf(red("Selection cancelled: %v"), err)
+	}
+
+	var selected []candidate
+	for _, label := range picked {
+		for _, c := range candidates {
+			if c.label() == label {
+				selected = append(selected, c)
+			}
+		}
+	}
+	return selected
+}
+
+// protectedPatterns returns the branch name patterns that must never be deleted
+func protectedPatterns(base string) []string {
+	patterns := append([]string{}, defaultProtected...)
+	patterns = append(patterns, strings.TrimPrefix(base, cfg.Remote+"/"))
+	return append(patterns, nonEmptyLines(gitOutput("config", "--get-all", "gitcleanup.protected"))...)
+}
+
+func isProtected(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok || pattern == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultBranch returns the remote default branch, falling back to a local main or master
+func defaultBranch() (string, error) {
+	if ref := strings.TrimSpace(gitOutput("symbolic-ref", "--quiet", "--short", "refs/remotes/"+cfg.Remote+"/HEAD")); ref != "" {
+		return ref, nil
+	}
+	for _, name := range []string{"main", "master"} {
+		if refExists("refs/remotes/" + cfg.Remote + "/" + name) {
+			return cfg.Remote + "/" + name, nil
+		}
+	}
+	for _, name := range []string{"main", "master"} {
+		if refExists("refs/heads/" + name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no main or master branch found")
+}
+
+func staleDays(unix string) int {
+	if cfg.StaleDays <= 0 {
+		return 0
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return 0
+	}
+	days := int(time.Since(time.Unix(seconds, 0)).Hours() / 24)
+	if days < cfg.StaleDays {
+		return 0
+	}
+	return days
+}
+
+func contains(candidates []candidate, name string) bool {
+	for _, c := range candidates {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRemote(remote string) bool {
+	for _, name := range strings.Fields(gitOutput("remote")) {
+		if name == remote {
+			return true
+		}
+	}
+	return false
+}
+
+func isAncestor(ancestor, descendant string) bool {
+	return exec.Command("git", "merge-base", "--is-ancestor", ancestor, descendant).Run() == nil
+}
+
+func refExists(ref string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", ref).Run() == nil
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+func gitOutput(args ...string) string {
+	output, _ := exec.Command("git", args...).Output()
+	return string(output)
+}
+
+func runCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
+
+func logError(message string, err error) {
+	log.Printf("%s %s: %v", red("✗"), message, err)
+}
+
+func startSpinner(message string) {
+	s = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + message
+	s.Start()
+}
+
+func stopSpinner() {
+	s.Stop()
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of diff lines, as they are marked in a unified diff
+const (
+	lineContext = ' '
+	lineAdded   = '+'
+	lineDeleted = '-'
+)
+
+// File statuses
+const (
+	statusModified = "modified"
+	statusAdded    = "added"
+	statusDeleted  = "deleted"
+	statusRenamed  = "renamed"
+	statusCopied   = "copied"
+	statusConflict = "conflicted"
+)
+
+// hunkPattern matches "@@ -1,4 +1,5 @@ section"; a missing count means 1
+var hunkPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// Line is one line of a hunk with its line numbers; OldNo is 0 for added
+// lines and NewNo is 0 for deleted ones
+type Line struct {
+	Kind       byte
+	Text       string
+	OldNo      int
+	NewNo      int
+	NoNewline  bool // "\ No newline at end of file" followed it
+	Moved      bool // part of a block moved elsewhere in the diff
+	Whitespace bool // replaced by a line that differs only in whitespace
+}
+
+// Hunk is one "@@" section of a file diff
+type Hunk struct {
+	Header   string
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	Section  string // function or heading git shows after the "@@"
+	Lines    []Line
+}
+
+// File is the diff of one file
+type File struct {
+	OldPath string
+	NewPath string
+	Status  string
+	Binary  bool
+	Mode    string   // "old → new" when the file mode changed
+	Header  []string // the "diff --git" line and the extended headers, as git printed them
+	Hunks   []Hunk
+	// Combined is set for the combined diff ("diff --cc") of a conflicted
+	// file, which has a column per parent; its hunks are not parsed
+	Combined bool
+}
+
+// Name is the path to show for the file
+func (f File) Name() string {
+	switch {
+	case f.Status == statusDeleted:
+		return f.OldPath
+	case f.OldPath != f.NewPath && f.OldPath != "":
+		return f.OldPath + " → " + f.NewPath
+	}
+	return f.NewPath
+}
+
+// Stats counts the added and deleted lines
+func (f File) Stats() (added, deleted int) {
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case lineAdded:
+				added++
+			case lineDeleted:
+				deleted++
+			}
+		}
+	}
+	return added, deleted
+}
+
+// parseDiff reads the output of git diff into files and hunks. Lines are
+// split on "\n" only, so a "\r" at the end of a line is kept and a change of
+// line endings shows up.
+func parseDiff(text string) []*File {
+	var files []*File
+	var file *File
+	var hunk *Hunk
+	// Lines still expected in the current hunk; while any are, a line is
+	// content even if it looks like a header
+	oldLeft, newLeft := 0, 0
+	oldNo, newNo := 0, 0
+
+	lines := strings.Split(text, "\n")
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		lines = lines[:n-1]
+	}
+	for _, line := range lines {
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			kind, text := byte(lineContext), ""
+			// Some tools strip the space of empty context lines
+			if line != "" {
+				kind, text = line[0], line[1:]
+			}
+			switch kind {
+			case lineContext:
+				oldNo, newNo, oldLeft, newLeft = oldNo+1, newNo+1, oldLeft-1, newLeft-1
+				hunk.Lines = append(hunk.Lines, Line{Kind: lineContext, Text: text, OldNo: oldNo, NewNo: newNo})
+			case lineDeleted:
+				oldNo, oldLeft = oldNo+1, oldLeft-1
+				hunk.Lines = append(hunk.Lines, Line{Kind: lineDeleted, Text: text, OldNo: oldNo})
+			case lineAdded:
+				newNo, newLeft = newNo+1, newLeft-1
+				hunk.Lines = append(hunk.Lines, Line{Kind: lineAdded, Text: text, NewNo: newNo})
+			case '\\':
+				if n := len(hunk.Lines); n > 0 {
+					hunk.Lines[n-1].NoNewline = true
+				}
+			}
+			continue
+		}
+		if hunk != nil && strings.HasPrefix(line, "\\") {
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].NoNewline = true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "diff --git ") {
+			oldPath, newPath := splitGitHeader(strings.TrimPrefix(line, "diff --git "))
+			file = &File{OldPath: oldPath, NewPath: newPath, Status: statusModified, Header: []string{line}}
+			files = append(files, file)
+			hunk = nil
+			continue
+		}
+		if path, ok := combinedHeader(line); ok {
+			file = &File{OldPath: path, NewPath: path, Status: statusConflict, Header: []string{line}, Combined: true}
+			files = append(files, file)
+			hunk = nil
+			continue
+		}
+		// "* Unmerged path" notes of git diff --ours, and the body of a
+		// combined diff up to the next file
+		if file == nil || file.Combined || strings.HasPrefix(line, "* Unmerged path ") {
+			continue
+		}
+
+		if match := hunkPattern.FindStringSubmatch(line); match != nil {
+			h := Hunk{Header: line, Section: match[5]}
+			h.OldStart, _ = strconv.Atoi(match[1])
+			h.OldCount = count(match[2])
+			h.NewStart, _ = strconv.Atoi(match[3])
+			h.NewCount = count(match[4])
+			file.Hunks = append(file.Hunks, h)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldNo, newNo = h.OldStart-1, h.NewStart-1
+			oldLeft, newLeft = h.OldCount, h.NewCount
+			continue
+		}
+
+		// Extended headers between "diff --git" and the first hunk
+		file.Header = append(file.Header, line)
+		switch {
+		case strings.HasPrefix(line, "new file mode"):
+			file.Status = statusAdded
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.Status = statusDeleted
+		case strings.HasPrefix(line, "rename from "):
+			file.Status, file.OldPath = statusRenamed, unquote(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			file.NewPath = unquote(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy from "):
+			file.Status, file.OldPath = statusCopied, unquote(strings.TrimPrefix(line, "copy from "))
+		case strings.HasPrefix(line, "copy to "):
+			file.NewPath = unquote(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "old mode "):
+			file.Mode = strings.TrimPrefix(line, "old mode ")
+		case strings.HasPrefix(line, "new mode "):
+			file.Mode += " → " + strings.TrimPrefix(line, "new mode ")
+		case strings.HasPrefix(line, "--- ") && line != "--- /dev/null":
+			file.OldPath = strings.TrimPrefix(unquote(markerPath(line)), "a/")
+		case strings.HasPrefix(line, "+++ ") && line != "+++ /dev/null":
+			file.NewPath = strings.TrimPrefix(unquote(markerPath(line)), "b/")
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			file.Binary = true
+		}
+	}
+	return files
+}
+
+// combinedHeader recognizes the "diff --cc path" and "diff --combined path"
+// headers git prints for conflicted files
+func combinedHeader(line string) (string, bool) {
+	for _, prefix := range []string{"diff --cc ", "diff --combined "} {
+		if strings.HasPrefix(line, prefix) {
+			return unquote(strings.TrimPrefix(line, prefix)), true
+		}
+	}
+	return "", false
+}
+
+// splitGitHeader finds the paths in "a/old b/new". They are ambiguous when
+// they contain " b/", so the ---, +++ and rename lines correct them later.
+func splitGitHeader(paths string) (string, string) {
+	if strings.HasPrefix(paths, `"`) {
+		if old, err := strconv.QuotedPrefix(paths); err == nil {
+			return strings.TrimPrefix(unquote(old), "a/"), strings.TrimPrefix(unquote(strings.TrimSpace(paths[len(old):])), "b/")
+		}
+	}
+	// With identical paths the header is "a/" + p + " b/" + p
+	if n := len(paths); n%2 == 1 {
+		if old, new := paths[:n/2], paths[n/2+1:]; strings.TrimPrefix(old, "a/") == strings.TrimPrefix(new, "b/") {
+			return strings.TrimPrefix(old, "a/"), strings.TrimPrefix(new, "b/")
+		}
+	}
+	if i := strings.Index(paths, " b/"); i >= 0 {
+		return strings.TrimPrefix(paths[:i], "a/"), paths[i+3:]
+	}
+	return paths, paths
+}
+
+// markerPath is the path of a "---" or "+++" line; git ends it with a tab
+// when the path has a space in it
+func markerPath(line string) string {
+	return strings.TrimSuffix(line[4:], "\t")
+}
+
+// unquote decodes the C-style quoting git uses for unusual paths
+func unquote(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if s, err := strconv.Unquote(path); err == nil {
+			return s
+		}
+	}
+	return path
+}
+
+func count(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// filterLines keeps only the added or the deleted lines, with the context
+// around them; hunks and files left without changes are dropped. The file
+// and hunk headers are kept as they were, so line numbers still match.
+func filterLines(files []*File, keep byte) []*File {
+	var kept []*File
+	for _, f := range files {
+		var hunks []Hunk
+		for _, h := range f.Hunks {
+			var lines []Line
+			changed := false
+			for _, l := range h.Lines {
+				if l.Kind != lineContext && l.Kind != keep {
+					continue
+				}
+				changed = changed || l.Kind == keep
+				lines = append(lines, l)
+			}
+			if changed {
+				h.Lines = lines
+				hunks = append(hunks, h)
+			}
+		}
+		if len(hunks) > 0 {
+			f.Hunks = hunks
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// changeBlock is a run of deleted lines followed by the added lines that
+// replace them
+type changeBlock struct {
+	Deleted []Line
+	Added   []Line
+}
+
+// Pairs is how many deleted lines line up with added ones
+func (b changeBlock) Pairs() int {
+	return min(len(b.Deleted), len(b.Added))
+}
+
+// hunkItems splits a hunk into context lines and change blocks, in order;
+// exactly one field of each item is set. The items share the hunk's lines,
+// so flags set through them stick to the hunk.
+type hunkItem struct {
+	Context *Line
+	Change  *changeBlock
+}
+
+func hunkItems(h Hunk) []hunkItem {
+	var items []hunkItem
+	for i := 0; i < len(h.Lines); {
+		if h.Lines[i].Kind == lineContext {
+			items = append(items, hunkItem{Context: &h.Lines[i]})
+			i++
+			continue
+		}
+		// A deletion after additions starts a new block
+		start := i
+		for i < len(h.Lines) && h.Lines[i].Kind == lineDeleted {
+			i++
+		}
+		middle := i
+		for i < len(h.Lines) && h.Lines[i].Kind == lineAdded {
+			i++
+		}
+		items = append(items, hunkItem{Change: &changeBlock{Deleted: h.Lines[start:middle:middle], Added: h.Lines[middle:i:i]}})
+	}
+	return items
+}
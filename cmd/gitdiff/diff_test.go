@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// lineSummary shows a line as "kind old new text", with "$" for a missing
+// newline at the end of the file
+func lineSummary(l Line) string {
+	s := fmt.Sprintf("%c %d %d %q", l.Kind, l.OldNo, l.NewNo, l.Text)
+	if l.NoNewline {
+		s += " $"
+	}
+	return s
+}
+
+func TestParseDiff(t *testing.T) {
+	type wantFile struct {
+		OldPath, NewPath string
+		Status           string
+		Binary           bool
+		Mode             string
+		Combined         bool
+		Lines            []string
+	}
+	tests := []struct {
+		name string
+		text string
+		want []wantFile
+	}{
+		{name: "empty", text: "", want: nil},
+		{
+			name: "modified file",
+			text: "diff --git a/main.go b/main.go\nindex 1111111..2222222 100644\n--- a/main.go\n+++ b/main.go\n" +
+				"@@ -1,3 +1,3 @@ package main\n a\n-b\n+B\n c\n",
+			want: []wantFile{{OldPath: "main.go", NewPath: "main.go", Status: statusModified,
+				Lines: []string{`  1 1 "a"`, `- 2 0 "b"`, `+ 0 2 "B"`, `  3 3 "c"`}}},
+		},
+		{
+			name: "new file without a newline at the end",
+			text: "diff --git a/new.txt b/new.txt\nnew file mode 100644\nindex 0000000..3333333\n--- /dev/null\n+++ b/new.txt\n" +
+				"@@ -0,0 +1,2 @@\n+one\n+two\n\\ No newline at end of file\n",
+			want: []wantFile{{OldPath: "new.txt", NewPath: "new.txt", Status: statusAdded,
+				Lines: []string{`+ 0 1 "one"`, `+ 0 2 "two" $`}}},
+		},
+		{
+			name: "deleted file",
+			text: "diff --git a/old.txt b/old.txt\ndeleted file mode 100644\nindex 3333333..0000000\n--- a/old.txt\n+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n-gone\n",
+			want: []wantFile{{OldPath: "old.txt", NewPath: "old.txt", Status: statusDeleted,
+				Lines: []string{`- 1 0 "gone"`}}},
+		},
+		{
+			name: "rename and mode change",
+			text: "diff --git a/run.sh b/bin/run.sh\nold mode 100644\nnew mode 100755\nsimilarity index 100%\n" +
+				"rename from run.sh\nrename to bin/run.sh\n",
+			want: []wantFile{{OldPath: "run.sh", NewPath: "bin/run.sh", Status: statusRenamed, Mode: "100644 → 100755"}},
+		},
+		{
+			name: "binary file",
+			text: "diff --git a/logo.png b/logo.png\nindex 4444444..5555555 100644\nBinary files a/logo.png and b/logo.png differ\n",
+			want: []wantFile{{OldPath: "logo.png", NewPath: "logo.png", Status: statusModified, Binary: true}},
+		},
+		{
+			name: "content that looks like headers and carriage returns",
+			text: "diff --git a/notes.md b/notes.md\nindex 6666666..7777777 100644\n--- a/notes.md\n+++ b/notes.md\n" +
+				"@@ -1,2 +1,2 @@\n--- a rule\n+++ b rule\n keep\r\n",
+			want: []wantFile{{OldPath: "notes.md", NewPath: "notes.md", Status: statusModified,
+				Lines: []string{`- 1 0 "-- a rule"`, `+ 0 1 "++ b rule"`, `  2 2 "keep\r"`}}},
+		},
+		{
+			name: "conflicted file next to a modified one",
+			text: "* Unmerged path conflict.txt\ndiff --cc conflict.txt\nindex 8888888,9999999..0000000\n--- a/conflict.txt\n+++ b/conflict.txt\n" +
+				"@@@ -1,1 -1,1 +1,5 @@@\n++<<<<<<< HEAD\n +ours\n++=======\n+ theirs\n++>>>>>>> topic\n" +
+				"diff --git a/other.txt b/other.txt\nindex aaaaaaa..bbbbbbb 100644\n--- a/other.txt\n+++ b/other.txt\n" +
+				"@@ -1 +1 @@\n-x\n+y\n",
+			want: []wantFile{
+				{OldPath: "conflict.txt", NewPath: "conflict.txt", Status: statusConflict, Combined: true},
+				{OldPath: "other.txt", NewPath: "other.txt", Status: statusModified, Lines: []string{`- 1 0 "x"`, `+ 0 1 "y"`}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []wantFile
+			for _, f := range parseDiff(tt.text) {
+				w := wantFile{OldPath: f.OldPath, NewPath: f.NewPath, Status: f.Status, Binary: f.Binary,
+					Mode: f.Mode, Combined: f.Combined}
+				for _, h := range f.Hunks {
+					for _, l := range h.Lines {
+						w.Lines = append(w.Lines, lineSummary(l))
+					}
+				}
+				got = append(got, w)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDiff =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDiffHunkHeader(t *testing.T) {
+	files := parseDiff("diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -10,2 +12 @@ func main() {\n-a\n b\n")
+	if len(files) != 1 || len(files[0].Hunks) != 1 {
+		t.Fatalf("parseDiff = %+v, want one file with one hunk", files)
+	}
+	h := files[0].Hunks[0]
+	got := []int{h.OldStart, h.OldCount, h.NewStart, h.NewCount}
+	if want := []int{10, 2, 12, 1}; !reflect.DeepEqual(got, want) || h.Section != "func main() {" {
+		t.Errorf("hunk = %v %q, want %v %q", got, h.Section, want, "func main() {")
+	}
+	if l := h.Lines[1]; l.OldNo != 11 || l.NewNo != 12 {
+		t.Errorf("context line numbers = %d, %d, want 11, 12", l.OldNo, l.NewNo)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// maxBarWidth is the width in pixels of the stats bar of the most changed file
+const maxBarWidth = 100
+
+// tokenClasses are the CSS classes of syntax tokens in the HTML export
+var tokenClasses = map[int]string{
+	tokenKeyword: "tok-keyword",
+	tokenString:  "tok-string",
+	tokenComment: "tok-comment",
+	tokenNumber:  "tok-number",
+}
+
+// htmlExport is what the HTML template is given
+type htmlExport struct {
+	Title      string
+	Repository string
+	Generated  string
+	Added      int
+	Deleted    int
+	Files      []htmlFile
+}
+
+type htmlFile struct {
+	ID         string
+	Name       string
+	Details    string
+	Binary     bool
+	Combined   bool
+	Added      int
+	Deleted    int
+	BarAdded   int
+	BarDeleted int
+	Hunks      []htmlHunk
+}
+
+type htmlHunk struct {
+	Header string
+	Rows   [][2]splitSide
+}
+
+// writeHTML exports the files as one self-contained HTML page, side by side
+// with a summary of the changes at the top
+func writeHTML(path string, files []*File, title string) error {
+	tmpl, err := template.New("export.html.tmpl").Funcs(template.FuncMap{"cell": htmlCell}).ParseFS(templates, "templates/export.html.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to load the HTML template: %v", err)
+	}
+
+	export := htmlExport{Title: title, Generated: time.Now().Format("2006-01-02 15:04")}
+	if top, err := gitOutput("rev-parse", "--show-toplevel"); err == nil {
+		export.Repository = filepath.Base(top)
+	}
+	most := 0
+	for i, f := range files {
+		added, deleted := f.Stats()
+		export.Added += added
+		export.Deleted += deleted
+		most = max(most, added+deleted)
+
+		details := []string{f.Status}
+		if f.Mode != "" {
+			details = append(details, "mode "+f.Mode)
+		}
+		file := htmlFile{ID: fmt.Sprintf("file-%d", i+1), Name: f.Name(), Details: "(" + strings.Join(details, ", ") + ")",
+			Binary: f.Binary, Combined: f.Combined, Added: added, Deleted: deleted}
+		for _, h := range f.Hunks {
+			file.Hunks = append(file.Hunks, htmlHunk{Header: h.Header, Rows: splitPairs(h, newHighlighter(f))})
+		}
+		export.Files = append(export.Files, file)
+	}
+	for i := range export.Files {
+		if most > 0 {
+			export.Files[i].BarAdded = export.Files[i].Added * maxBarWidth / most
+			export.Files[i].BarDeleted = export.Files[i].Deleted * maxBarWidth / most
+		}
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, export); err != nil {
+		return fmt.Errorf("failed to render the HTML: %v", err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// htmlCell renders one side of a row as its line number and text cells
+func htmlCell(side splitSide) template.HTML {
+	if !side.Present {
+		return `<td class="num empty"></td><td class="code empty"></td>`
+	}
+	classes := []string{"code"}
+	switch side.Look.Kind {
+	case lineDeleted:
+		classes = append(classes, "del")
+	case lineAdded:
+		classes = append(classes, "add")
+	}
+	if side.Look.Moved {
+		classes = append(classes, "moved")
+	}
+	if side.Look.Dim {
+		classes = append(classes, "ws")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<td class="num">%d</td><td class="%s">`, side.Number, strings.Join(classes, " "))
+	for _, s := range side.Segs {
+		var spans []string
+		if class, ok := tokenClasses[s.Token]; ok {
+			spans = append(spans, class)
+		}
+		if s.Changed {
+			spans = append(spans, "word")
+		}
+		text := template.HTMLEscapeString(strings.ReplaceAll(s.Text, "\r", carriageReturn))
+		if len(spans) > 0 {
+			fmt.Fprintf(&b, `<span class="%s">%s</span>`, strings.Join(spans, " "), text)
+		} else {
+			b.WriteString(text)
+		}
+	}
+	b.WriteString("</td>")
+	return template.HTML(b.String())
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Staged        bool
+	Split         bool
+	Words         bool
+	AdditionsOnly bool
+	DeletionsOnly bool
+	Context       int
+	Width         int
+	NoPager       bool
+	NoSyntax      bool
+	NoMoved       bool
+	HTML          string
+	Patches       string
+	Title         string
+	Message       string
+	VerboseMode   bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "gitdiff [revision or range...] [-- path...]",
+		Short: "Show a Git diff unified, side by side or word by word",
+		Long: `Show a Git diff unified, side by side or word by word.
+
+Without arguments the unstaged changes are shown; --staged shows the staged
+ones. Revisions are passed to git diff, so commit ranges (HEAD~3..HEAD) and
+branch comparisons (main...feature, the changes on feature since it left
+main) work as they do there. Paths after -- limit the diff.
+
+The unified view numbers the old and new lines. --split puts the old and new
+versions side by side, fitted to the terminal width, and --words shows each
+changed line once with the removed and added words marked. Both highlight the
+words that changed within a line.
+
+Code is colored by its language, picked from the file extension; changed
+lines then get a green or red background instead. Blocks of at least three
+lines that were deleted in one place and added in another, in the same file or
+a different one, are shown as moved in magenta and cyan rather than as a
+deletion and an addition. Lines that only changed in whitespace are dimmed.
+
+--additions-only and --deletions-only leave out the other kind of change but
+keep the file and hunk headers, so line numbers still match the files.
+
+--html writes the diff to a self-contained HTML page for sharing offline: a
+summary of the changed files, then each file side by side, collapsible.
+--patches writes the commits of a range as a patch series with a cover letter,
+like git format-patch --cover-letter, into a directory, or into one mailbox
+file when the name ends in .mbox. --title and --message fill in the cover
+letter; git am --empty=drop applies the series, skipping the cover letter.`,
+		Args: cobra.ArbitraryArgs,
+		Run:  gitDiff,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().BoolVarP(&cfg.Staged, "staged", "s", false, "Show the staged changes")
+	rootCmd.Flags().BoolVarP(&cfg.Split, "split", "y", false, "Show old and new side by side")
+	rootCmd.Flags().BoolVarP(&cfg.Words, "words", "w", false, "Show changed lines once with the changed words marked")
+	rootCmd.Flags().BoolVarP(&cfg.AdditionsOnly, "additions-only", "a", false, "Only show added lines")
+	rootCmd.Flags().BoolVarP(&cfg.DeletionsOnly, "deletions-only", "d", false, "Only show deleted lines")
+	rootCmd.Flags().IntVarP(&cfg.Context, "unified", "U", 3, "Lines of context around each change")
+	rootCmd.Flags().IntVar(&cfg.Width, "width", 0, "Width of the output (default: the terminal width)")
+	rootCmd.Flags().BoolVar(&cfg.NoPager, "no-pager", false, "Do not page the output")
+	rootCmd.Flags().BoolVar(&cfg.NoSyntax, "no-syntax", false, "Do not color the code by its language")
+	rootCmd.Flags().BoolVar(&cfg.NoMoved, "no-moved", false, "Do not mark blocks of code that moved")
+	rootCmd.Flags().StringVar(&cfg.HTML, "html", "", "Export the diff as a self-contained HTML file")
+	rootCmd.Flags().StringVar(&cfg.Patches, "patches", "", "Export the commits as a patch series with a cover letter, to a directory or an .mbox file")
+	rootCmd.Flags().StringVar(&cfg.Title, "title", "", "Title of the HTML export and subject of the cover letter")
+	rootCmd.Flags().StringVarP(&cfg.Message, "message", "m", "", "Text of the cover letter")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.MarkFlagsMutuallyExclusive("split", "words")
+	rootCmd.MarkFlagsMutuallyExclusive("additions-only", "deletions-only")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func gitDiff(cmd *cobra.Command, args []string) {
+	revisions, paths := args, []string(nil)
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		revisions, paths = args[:dash], args[dash:]
+	}
+
+	if cfg.Patches != "" {
+		n, err := writePatches(cfg.Patches, revisions, paths, cfg.Title, cfg.Message)
+		if err != nil {
+			log.Fatalf(red("%v"), err)
+		}
+		fmt.Println(green(fmt.Sprintf("Wrote %d patch%s and a cover letter to %s", n, plural(n, "es"), cfg.Patches)))
+		if cfg.HTML == "" {
+			return
+		}
+	}
+
+	files, err := loadDiff(revisions, paths)
+	if err != nil {
+		log.Fatalf(red("%v"), err)
+	}
+	annotate(files)
+	switch {
+	case cfg.AdditionsOnly:
+		files = filterLines(files, lineAdded)
+	case cfg.DeletionsOnly:
+		files = filterLines(files, lineDeleted)
+	}
+	if len(files) == 0 {
+		fmt.Println(yellow("No changes to show in the diff."))
+		if !cfg.Staged && len(revisions) == 0 {
+			if staged, _ := gitOutput("diff", "--cached", "--name-only"); staged != "" {
+				fmt.Println("There are staged changes; see them with gitdiff --staged.")
+			}
+		}
+		return
+	}
+
+	if cfg.HTML != "" {
+		if err := writeHTML(cfg.HTML, files, exportTitle(revisions, paths)); err != nil {
+			log.Fatalf(red("%v"), err)
+		}
+		fmt.Println(green(fmt.Sprintf("Wrote the diff of %d file%s to %s", len(files), plural(len(files), "s"), cfg.HTML)))
+		return
+	}
+
+	width := terminalWidth()
+	view := viewUnified
+	switch {
+	case cfg.Split:
+		view = viewSplit
+		numWidth := 0
+		for _, f := range files {
+			numWidth = max(numWidth, lineNumberWidth(f))
+		}
+		if splitColumn(width, numWidth) < minSplitColumn {
+			fmt.Fprintln(os.Stderr, yellow(fmt.Sprintf("The terminal is too narrow for side by side (%d columns); showing the unified view.", width)))
+			view = viewUnified
+		}
+	case cfg.Words:
+		view = viewWords
+	}
+
+	var out bytes.Buffer
+	renderFiles(&out, files, view, width)
+	page(out.Bytes())
+}
+
+// loadDiff runs git diff and parses its output
+func loadDiff(revisions, paths []string) ([]*File, error) {
+	// Fixed prefixes and quoting, whatever the user's configuration
+	args := []string{"-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/",
+		"-U" + strconv.Itoa(cfg.Context)}
+	if cfg.Staged {
+		args = append(args, "--cached")
+	}
+	if !cfg.Staged && len(revisions) == 0 {
+		// During a conflicted merge git shows conflicted files as combined
+		// diffs with a column per side; compare them with our side instead
+		if conflicts, _ := gitOutput("diff", "--name-only", "--diff-filter=U"); conflicts != "" {
+			fmt.Fprintln(os.Stderr, yellow("Conflicted files are compared with our side (HEAD), as git diff --ours does: "+strings.Join(strings.Split(conflicts, "\n"), ", ")))
+			args = append(args, "--ours")
+		}
+	}
+	args = append(args, revisions...)
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	output, err := gitOutput(args...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %v", err)
+	}
+	return parseDiff(output), nil
+}
+
+// exportTitle is --title, or a description of what is compared
+func exportTitle(revisions, paths []string) string {
+	if cfg.Title != "" {
+		return cfg.Title
+	}
+	title := "Unstaged changes"
+	switch {
+	case len(revisions) > 0:
+		title = "Diff of " + strings.Join(revisions, " ")
+	case cfg.Staged:
+		title = "Staged changes"
+	}
+	if len(paths) > 0 {
+		title += " in " + strings.Join(paths, ", ")
+	}
+	return title
+}
+
+func plural(n int, suffix string) string {
+	if n == 1 {
+		return ""
+	}
+	return suffix
+}
+
+// terminalWidth is --width, the width of the terminal or $COLUMNS, or 120
+func terminalWidth() int {
+	if cfg.Width > 0 {
+		return cfg.Width
+	}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 120
+}
+
+// page writes the output through Git's pager when it does not fit on the
+// terminal, like git diff does
+func page(output []byte) {
+	fd := int(os.Stdout.Fd())
+	_, height, err := term.GetSize(fd)
+	if cfg.NoPager || err != nil || !term.IsTerminal(fd) || bytes.Count(output, []byte("\n")) < height {
+		os.Stdout.Write(output)
+		return
+	}
+	pager, _ := gitOutput("var", "GIT_PAGER")
+	if pager == "" || pager == "cat" {
+		os.Stdout.Write(output)
+		return
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		// What git sets: quit if one screen, keep colors, leave the screen as is
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	logVerbose("Paging with " + pager)
+	if err := cmd.Start(); err != nil {
+		os.Stdout.Write(output)
+		return
+	}
+	cmd.Wait()
+}
+
+// gitOutput runs a git command and returns its stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logVerbose("git " + strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow("→"), message)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A run of deleted lines that shows up again as added lines counts as moved
+// when it is at least minMovedLines long and has minMovedChars letters and
+// digits, so that runs of braces and blank lines do not
+const (
+	minMovedLines = 3
+	minMovedChars = 20
+)
+
+// annotate marks the lines of blocks that moved and the line pairs that
+// differ only in whitespace, for the renderer to show them apart from real
+// additions and deletions
+func annotate(files []*File) {
+	if !cfg.NoMoved {
+		markMoves(files)
+	}
+	for _, f := range files {
+		for _, h := range f.Hunks {
+			for _, item := range hunkItems(h) {
+				if block := item.Change; block != nil {
+					markWhitespace(block)
+				}
+			}
+		}
+	}
+}
+
+// moveTarget is an added line that a moved block could land on
+type moveTarget struct {
+	Run   []Line // the added lines of its change block
+	At    int
+	Block int // index of the change block, so a block does not move onto itself
+}
+
+// markMoves finds runs of deleted lines that were added again elsewhere in
+// the diff, in the same file or another one. Indentation is ignored, so code
+// moved into or out of a block still counts. Lines re-added in their own
+// change block are left to the whitespace check.
+func markMoves(files []*File) {
+	var deleted [][]Line
+	targets := map[string][]moveTarget{}
+	for _, f := range files {
+		for _, h := range f.Hunks {
+			for _, item := range hunkItems(h) {
+				if item.Change == nil {
+					continue
+				}
+				block := len(deleted)
+				deleted = append(deleted, item.Change.Deleted)
+				run := item.Change.Added
+				for i, l := range run {
+					if key := moveKey(l.Text); key != "" {
+						targets[key] = append(targets[key], moveTarget{Run: run, At: i, Block: block})
+					}
+				}
+			}
+		}
+	}
+
+	for block, run := range deleted {
+		for i := 0; i < len(run); {
+			// The longest run of added lines that matches from here
+			best, bestTarget := 0, moveTarget{}
+			for _, t := range targets[moveKey(run[i].Text)] {
+				if t.Block == block {
+					continue
+				}
+				n := 0
+				for i+n < len(run) && t.At+n < len(t.Run) && !t.Run[t.At+n].Moved &&
+					moveKey(run[i+n].Text) == moveKey(t.Run[t.At+n].Text) {
+					n++
+				}
+				if n > best {
+					best, bestTarget = n, t
+				}
+			}
+			if best < minMovedLines || alnumCount(run[i:i+best]) < minMovedChars {
+				i++
+				continue
+			}
+			for n := 0; n < best; n++ {
+				run[i+n].Moved = true
+				bestTarget.Run[bestTarget.At+n].Moved = true
+			}
+			i += best
+		}
+	}
+}
+
+// markWhitespace flags the line pairs of a block that only differ in
+// whitespace
+func markWhitespace(block *changeBlock) {
+	for i := 0; i < block.Pairs(); i++ {
+		old, new := &block.Deleted[i], &block.Added[i]
+		if old.Moved || new.Moved || old.Text == new.Text {
+			continue
+		}
+		if withoutSpace(old.Text) == withoutSpace(new.Text) {
+			old.Whitespace, new.Whitespace = true, true
+		}
+	}
+}
+
+func moveKey(text string) string {
+	return strings.TrimSpace(text)
+}
+
+func withoutSpace(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func alnumCount(lines []Line) int {
+	n := 0
+	for _, l := range lines {
+		for _, r := range l.Text {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// movedLines lists the lines marked as moved, as "file kind text"
+func movedLines(files []*File) []string {
+	var moved []string
+	for _, f := range files {
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Moved {
+					moved = append(moved, f.NewPath+" "+string(l.Kind)+" "+l.Text)
+				}
+			}
+		}
+	}
+	return moved
+}
+
+func TestMarkMoves(t *testing.T) {
+	fileDiff := func(name, hunk string) string {
+		return "diff --git a/" + name + " b/" + name + "\n--- a/" + name + "\n+++ b/" + name + "\n" + hunk
+	}
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "block moved to another file",
+			text: fileDiff("a.go", "@@ -1,4 +1,1 @@\n keep\n-total := 0\n-for _, n := range nums {\n-\ttotal += n\n") +
+				fileDiff("b.go", "@@ -1,1 +1,4 @@\n keep\n+\ttotal := 0\n+\tfor _, n := range nums {\n+\t\ttotal += n\n"),
+			want: []string{
+				"a.go - total := 0", "a.go - for _, n := range nums {", "a.go - \ttotal += n",
+				"b.go + \ttotal := 0", "b.go + \tfor _, n := range nums {", "b.go + \t\ttotal += n",
+			},
+		},
+		{
+			name: "block moved within a file",
+			text: fileDiff("a.go", "@@ -1,4 +1,1 @@\n-first line moved\n-second line moved\n-third line moved\n keep\n@@ -9,1 +6,4 @@\n end\n"+
+				"+first line moved\n+second line moved\n+third line moved\n"),
+			want: []string{
+				"a.go - first line moved", "a.go - second line moved", "a.go - third line moved",
+				"a.go + first line moved", "a.go + second line moved", "a.go + third line moved",
+			},
+		},
+		{
+			name: "re-indented in place",
+			text: fileDiff("a.go", "@@ -1,3 +1,3 @@\n-first line kept\n-second line kept\n-third line kept\n"+
+				"+\tfirst line kept\n+\tsecond line kept\n+\tthird line kept\n"),
+			want: nil,
+		},
+		{
+			name: "too few lines",
+			text: fileDiff("a.go", "@@ -1,2 +1,0 @@\n-a line long enough to count\n-another long enough line\n") +
+				fileDiff("b.go", "@@ -0,0 +1,2 @@\n+a line long enough to count\n+another long enough line\n"),
+			want: nil,
+		},
+		{
+			name: "too few letters and digits",
+			text: fileDiff("a.go", "@@ -1,3 +1,0 @@\n-\t}\n-}\n-\n") +
+				fileDiff("b.go", "@@ -0,0 +1,3 @@\n+\t}\n+}\n+\n"),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := parseDiff(tt.text)
+			markMoves(files)
+			if got := movedLines(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("moved lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Placeholders git format-patch leaves in the cover letter
+const (
+	subjectPlaceholder = "*** SUBJECT HERE ***"
+	blurbPlaceholder   = "*** BLURB HERE ***"
+)
+
+// writePatches exports the commits of a range as a patch series with a
+// cover letter, like git format-patch --cover-letter, with the cover letter
+// filled in. A target ending in .mbox gets the whole series as one mailbox
+// file; any other target is a directory of numbered .patch files.
+func writePatches(target string, revisions, paths []string, title, message string) (int, error) {
+	if cfg.Staged {
+		return 0, fmt.Errorf("--patches exports commits, not staged changes")
+	}
+	commits, err := patchRange(revisions)
+	if err != nil {
+		return 0, err
+	}
+	// Limited to the paths, as format-patch is
+	count, err := gitOutput(append([]string{"rev-list", "--count", "--no-merges", commits, "--"}, paths...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the commits of %s: %v", commits, err)
+	}
+	n, _ := strconv.Atoi(count)
+	if n == 0 {
+		return 0, fmt.Errorf("no commits in %s to export", commits)
+	}
+	if title == "" {
+		title = "Changes on " + tipName(commits)
+	}
+
+	args := []string{"--cover-letter", "-U" + strconv.Itoa(cfg.Context), commits}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	if strings.HasSuffix(target, ".mbox") {
+		mbox, err := gitOutput(append([]string{"format-patch", "--stdout"}, args...)...)
+		if err != nil {
+			return 0, fmt.Errorf("git format-patch failed: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(fillCoverLetter(mbox, title, message)+"\n"), 0644); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %v", target, err)
+		}
+		return n, nil
+	}
+
+	output, err := gitOutput(append([]string{"format-patch", "-o", target}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("git format-patch failed: %v", err)
+	}
+	written := nonEmptyLines(output)
+	if len(written) == 0 {
+		return 0, fmt.Errorf("git format-patch wrote no patches")
+	}
+	// The cover letter comes first
+	cover, err := os.ReadFile(written[0])
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the cover letter: %v", err)
+	}
+	if err := os.WriteFile(written[0], []byte(fillCoverLetter(string(cover), title, message)), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write the cover letter: %v", err)
+	}
+	for _, path := range written {
+		logVerbose("Wrote " + path)
+	}
+	return len(written) - 1, nil
+}
+
+// patchRange turns the revisions into the commit range format-patch takes.
+// One revision means the commits since it, as in git format-patch; two are
+// the commits from the first to the second; A...B is the commits on B since
+// it left A, like the diff of A...B shows.
+func patchRange(revisions []string) (string, error) {
+	switch len(revisions) {
+	case 1:
+		rev := revisions[0]
+		if from, to, ok := strings.Cut(rev, "..."); ok {
+			from, to = orHead(from), orHead(to)
+			base, err := gitOutput("merge-base", from, to)
+			if err != nil {
+				return "", fmt.Errorf("%s and %s have no common ancestor: %v", from, to, err)
+			}
+			return base + ".." + to, nil
+		}
+		if !strings.Contains(rev, "..") {
+			return rev + "..HEAD", nil
+		}
+		return rev, nil
+	case 2:
+		return revisions[0] + ".." + revisions[1], nil
+	}
+	return "", fmt.Errorf("--patches needs a commit range, e.g. gitdiff main..feature --patches outgoing/")
+}
+
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+// tipName names the end of a range, as a branch name where there is one
+func tipName(commits string) string {
+	_, tip, _ := strings.Cut(commits, "..")
+	tip = orHead(tip)
+	if name, err := gitOutput("rev-parse", "--abbrev-ref", tip); err == nil && name != "" && name != "HEAD" {
+		return name
+	}
+	return tip
+}
+
+// fillCoverLetter puts the subject and message in place of format-patch's
+// placeholders; without a message the placeholder line is left out. A
+// non-ASCII subject is encoded as RFC 2047 asks for mail headers, and a
+// non-ASCII message gets the headers that declare it UTF-8.
+func fillCoverLetter(cover, title, message string) string {
+	cover = strings.Replace(cover, subjectPlaceholder, mime.QEncoding.Encode("utf-8", title), 1)
+	if message == "" {
+		return strings.Replace(cover, blurbPlaceholder+"\n\n", "", 1)
+	}
+	if !isASCII(message) {
+		cover = declareUTF8(cover)
+	}
+	return strings.Replace(cover, blurbPlaceholder, strings.TrimSpace(message), 1)
+}
+
+// declareUTF8 adds the MIME headers for a UTF-8 body to the cover letter,
+// the first message, unless format-patch already did
+func declareUTF8(cover string) string {
+	headers, _, _ := strings.Cut(cover, "\n\n")
+	if strings.Contains(headers, "\nContent-Type:") {
+		return cover
+	}
+	return strings.Replace(cover, "\n\n", "\nMIME-Version: 1.0\nContent-Type: text/plain; charset=UTF-8\nContent-Transfer-Encoding: 8bit\n\n", 1)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// nonEmptyLines splits output into lines, dropping empty ones
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Views, chosen with --split and --words
+const (
+	viewUnified = "unified"
+	viewSplit   = "split"
+	viewWords   = "words"
+)
+
+// tabWidth is how many columns a tab takes up
+const tabWidth = 4
+
+// minSplitColumn is the narrowest text column the side-by-side view accepts
+const minSplitColumn = 20
+
+var (
+	fileStyle    = color.New(color.Bold)
+	hunkStyle    = color.New(color.FgCyan)
+	gutterStyle  = color.New(color.Faint)
+	addedStyle   = color.New(color.FgGreen)
+	deletedStyle = color.New(color.FgRed)
+	// Changed words within a changed line
+	addedWordStyle   = color.New(color.FgBlack, color.BgGreen)
+	deletedWordStyle = color.New(color.FgBlack, color.BgRed)
+	// Blocks that moved, in git's --color-moved colors
+	movedFromStyle = color.New(color.FgMagenta, color.Bold)
+	movedToStyle   = color.New(color.FgCyan, color.Bold)
+	// Lines that only changed in whitespace
+	dimStyle = color.New(color.Faint)
+
+	// Syntax colors, and the backgrounds that mark changed lines when the
+	// text is in syntax colors (dark green and red from the 256-color palette)
+	tokenColors = map[int]color.Attribute{
+		tokenKeyword: color.FgBlue,
+		tokenString:  color.FgYellow,
+		tokenComment: color.FgHiBlack,
+		tokenNumber:  color.FgMagenta,
+	}
+	addedBackground       = []color.Attribute{48, 5, 22}
+	addedWordBackground   = []color.Attribute{48, 5, 28}
+	deletedBackground     = []color.Attribute{48, 5, 52}
+	deletedWordBackground = []color.Attribute{48, 5, 88}
+)
+
+// look is how a line is colored: by its kind, in the moved colors, dimmed
+// when only its whitespace changed, or in syntax colors when its segments
+// carry tokens
+type look struct {
+	Kind   byte
+	Moved  bool
+	Dim    bool
+	Syntax bool
+}
+
+func lookOf(l Line, hl *highlighter) look {
+	return look{Kind: l.Kind, Moved: l.Moved, Dim: l.Whitespace, Syntax: hl.lang != nil}
+}
+
+// marker is the look of the +/- marker, which keeps the plain diff colors
+func (lk look) marker() look {
+	lk.Syntax = false
+	return lk
+}
+
+// paint colors the segments of a line
+func paint(lk look, segs []segment) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if style := styleFor(lk, s); style != nil {
+			b.WriteString(style.Sprint(s.Text))
+		} else {
+			b.WriteString(s.Text)
+		}
+	}
+	return b.String()
+}
+
+// styleFor picks the style of one segment, nil to leave it as it is
+func styleFor(lk look, s segment) *color.Color {
+	switch {
+	case lk.Moved && lk.Kind == lineDeleted:
+		return movedFromStyle
+	case lk.Moved && lk.Kind == lineAdded:
+		return movedToStyle
+	case lk.Dim:
+		return dimStyle
+	case !lk.Syntax:
+		switch {
+		case lk.Kind == lineAdded && s.Changed:
+			return addedWordStyle
+		case lk.Kind == lineDeleted && s.Changed:
+			return deletedWordStyle
+		case lk.Kind == lineAdded:
+			return addedStyle
+		case lk.Kind == lineDeleted:
+			return deletedStyle
+		}
+		return nil
+	}
+
+	var attrs []color.Attribute
+	if fg, ok := tokenColors[s.Token]; ok {
+		attrs = append(attrs, fg)
+	}
+	switch {
+	case lk.Kind == lineAdded && s.Changed:
+		attrs = append(attrs, addedWordBackground...)
+	case lk.Kind == lineAdded:
+		attrs = append(attrs, addedBackground...)
+	case lk.Kind == lineDeleted && s.Changed:
+		attrs = append(attrs, deletedWordBackground...)
+	case lk.Kind == lineDeleted:
+		attrs = append(attrs, deletedBackground...)
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return color.New(attrs...)
+}
+
+// renderFiles writes the files in the chosen view
+func renderFiles(w io.Writer, files []*File, view string, width int) {
+	for i, f := range files {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		renderFileHeader(w, f, width)
+		if f.Combined {
+			fmt.Fprintln(w, gutterStyle.Sprint("  combined diff of a conflicted file not shown; see git diff --ours or --theirs"))
+			continue
+		}
+		numWidth := lineNumberWidth(f)
+		for _, h := range f.Hunks {
+			fmt.Fprintln(w, hunkStyle.Sprint(h.Header))
+			// Each hunk starts afresh: what came before it is unknown
+			hl := newHighlighter(f)
+			switch view {
+			case viewSplit:
+				renderSplitHunk(w, h, hl, numWidth, width)
+			default:
+				renderUnifiedHunk(w, h, hl, numWidth, view == viewWords)
+			}
+		}
+	}
+}
+
+// renderFileHeader names the file with its status and line counts
+func renderFileHeader(w io.Writer, f *File, width int) {
+	added, deleted := f.Stats()
+	details := []string{f.Status}
+	if f.Mode != "" {
+		details = append(details, "mode "+f.Mode)
+	}
+	if f.Binary {
+		details = append(details, "binary")
+	}
+	title := fmt.Sprintf("━━ %s (%s) ", f.Name(), strings.Join(details, ", "))
+	counts := fmt.Sprintf("%s %s ", addedStyle.Sprintf("+%d", added), deletedStyle.Sprintf("-%d", deleted))
+	rule := width - len([]rune(title)) - len(fmt.Sprintf("+%d -%d ", added, deleted))
+	fmt.Fprintln(w, fileStyle.Sprint(title)+counts+fileStyle.Sprint(strings.Repeat("━", max(rule, 3))))
+}
+
+// lineNumberWidth is the number of digits of the largest line number in a file
+func lineNumberWidth(f *File) int {
+	largest := 0
+	for _, h := range f.Hunks {
+		largest = max(largest, h.OldStart+h.OldCount, h.NewStart+h.NewCount)
+	}
+	return len(strconv.Itoa(largest))
+}
+
+// gutter shows the old and new line numbers, blank where a line has none
+func gutter(l Line, numWidth int) string {
+	number := func(n int) string {
+		if n == 0 {
+			return strings.Repeat(" ", numWidth)
+		}
+		return fmt.Sprintf("%*d", numWidth, n)
+	}
+	return gutterStyle.Sprintf("%s %s │", number(l.OldNo), number(l.NewNo))
+}
+
+// noNewlineNote marks a line without a newline at the end of the file
+func noNewlineNote(l Line) string {
+	if l.NoNewline {
+		return gutterStyle.Sprint(" ⏎ no newline at end of file")
+	}
+	return ""
+}
+
+// renderUnifiedHunk writes a hunk one line after another like git does; in
+// the words view a changed line that pairs up with its replacement is shown
+// once with the changed words marked
+func renderUnifiedHunk(w io.Writer, h Hunk, hl *highlighter, numWidth int, words bool) {
+	line := func(l Line) {
+		lk := lookOf(l, hl)
+		segs := hl.line(l)
+		fmt.Fprintf(w, "%s%s%s%s\n", gutter(l, numWidth), paint(lk.marker(), []segment{{Text: string(l.Kind)}}), paint(lk, expandTabs(segs, tabWidth)), noNewlineNote(l))
+	}
+	for _, item := range hunkItems(h) {
+		if item.Context != nil {
+			line(*item.Context)
+			continue
+		}
+		block := item.Change
+		if !words {
+			for _, l := range block.Deleted {
+				line(l)
+			}
+			for _, l := range block.Added {
+				line(l)
+			}
+			continue
+		}
+
+		// Lines that pair up are merged; the rest are shown as they are
+		for i := 0; i < block.Pairs(); i++ {
+			old, new := block.Deleted[i], block.Added[i]
+			ops, ok := wordDiff(old.Text, new.Text)
+			if !ok || old.Moved || new.Moved || old.Whitespace {
+				line(old)
+				line(new)
+				continue
+			}
+			// The merged line mixes both versions, so it keeps the diff
+			// colors; the highlighter still has to see both lines
+			hl.line(old)
+			hl.line(new)
+			merged := Line{OldNo: old.OldNo, NewNo: new.NewNo, NoNewline: new.NoNewline}
+			fmt.Fprintf(w, "%s%s%s%s\n", gutter(merged, numWidth), hunkStyle.Sprint("~"), paintWords(ops), noNewlineNote(merged))
+		}
+		for _, l := range block.Deleted[block.Pairs():] {
+			line(l)
+		}
+		for _, l := range block.Added[block.Pairs():] {
+			line(l)
+		}
+	}
+}
+
+// paintWords writes a word diff as one line, marking the removed and added
+// words; without colors they are wrapped in [-...-] and {+...+} like git
+// diff --word-diff does
+func paintWords(ops []wordOp) string {
+	var b strings.Builder
+	for _, op := range ops {
+		text := op.Text
+		if color.NoColor {
+			switch op.Kind {
+			case lineDeleted:
+				text = "[-" + text + "-]"
+			case lineAdded:
+				text = "{+" + text + "+}"
+			}
+		}
+		segs := expandTabs(plainSegments(text), tabWidth)
+		if op.Kind != lineContext {
+			segs[0].Changed = true
+		}
+		b.WriteString(paint(look{Kind: op.Kind}, segs))
+	}
+	return b.String()
+}
+
+// splitColumn is the width left for text on each side of the side-by-side
+// view: each side is "number marker text" and the sides are split by " │ "
+func splitColumn(width, numWidth int) int {
+	return (width-3)/2 - numWidth - 2
+}
+
+// splitSide is one side of a row in the side-by-side view; a side without
+// a line is left blank
+type splitSide struct {
+	Present bool
+	Number  int
+	Look    look
+	Segs    []segment
+}
+
+// renderSplitHunk writes a hunk side by side, old on the left and new on the
+// right, wrapping long lines to fit the width
+func renderSplitHunk(w io.Writer, h Hunk, hl *highlighter, numWidth, width int) {
+	column := splitColumn(width, numWidth)
+	for _, pair := range splitPairs(h, hl) {
+		splitRow(w, pair[0], pair[1], numWidth, column)
+	}
+}
+
+// splitPairs lines a hunk up side by side: context lines on both sides and
+// deleted lines next to the added lines that replace them, with the changed
+// words marked
+func splitPairs(h Hunk, hl *highlighter) [][2]splitSide {
+	var pairs [][2]splitSide
+	for _, item := range hunkItems(h) {
+		if item.Context != nil {
+			l := *item.Context
+			segs := hl.line(l)
+			pairs = append(pairs, [2]splitSide{{true, l.OldNo, lookOf(l, hl), segs}, {true, l.NewNo, lookOf(l, hl), segs}})
+			continue
+		}
+		block := item.Change
+		for i := 0; i < max(len(block.Deleted), len(block.Added)); i++ {
+			var left, right splitSide
+			if i < len(block.Deleted) {
+				old := block.Deleted[i]
+				left = splitSide{true, old.OldNo, lookOf(old, hl), hl.line(old)}
+			}
+			if i < len(block.Added) {
+				new := block.Added[i]
+				right = splitSide{true, new.NewNo, lookOf(new, hl), hl.line(new)}
+			}
+			if i < block.Pairs() && !left.Look.Moved && !right.Look.Moved && !left.Look.Dim {
+				if ops, ok := wordDiff(block.Deleted[i].Text, block.Added[i].Text); ok {
+					left.Segs = overlay(left.Segs, sideSegments(ops, lineDeleted))
+					right.Segs = overlay(right.Segs, sideSegments(ops, lineAdded))
+				}
+			}
+			pairs = append(pairs, [2]splitSide{left, right})
+		}
+	}
+	return pairs
+}
+
+// splitRow writes one line pair, over several rows when either side wraps
+func splitRow(w io.Writer, left, right splitSide, numWidth, column int) {
+	leftRows := wrapSegments(expandTabs(left.Segs, tabWidth), column)
+	rightRows := wrapSegments(expandTabs(right.Segs, tabWidth), column)
+	for row := 0; row < max(len(leftRows), len(rightRows)); row++ {
+		fmt.Fprintf(w, "%s%s%s\n",
+			splitCell(row, left, leftRows, numWidth, column),
+			gutterStyle.Sprint(" │ "),
+			strings.TrimRight(splitCell(row, right, rightRows, numWidth, column), " "))
+	}
+}
+
+// splitCell renders one row of one side, padded to the column width
+func splitCell(row int, side splitSide, rows [][]segment, numWidth, column int) string {
+	gutter := strings.Repeat(" ", numWidth)
+	if row == 0 && side.Present {
+		gutter = fmt.Sprintf("%*d", numWidth, side.Number)
+	}
+	marker := " "
+	if row == 0 && side.Present && side.Look.Kind != lineContext {
+		marker = string(side.Look.Kind)
+	}
+	var segs []segment
+	if row < len(rows) {
+		segs = rows[row]
+	}
+	padding := strings.Repeat(" ", column-segmentsWidth(segs))
+	return gutterStyle.Sprint(gutter) + " " + paint(side.Look.marker(), []segment{{Text: marker}}) + paint(side.Look, segs) + padding
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Kinds of syntax tokens
+const (
+	tokenPlain = iota
+	tokenKeyword
+	tokenString
+	tokenComment
+	tokenNumber
+)
+
+// language describes enough of a language's syntax to color its tokens
+type language struct {
+	Name         string
+	Keywords     map[string]bool
+	IgnoreCase   bool      // keywords match in any case, as in SQL
+	LineComments []string  // e.g. "//" or "#"
+	BlockComment [2]string // opening and closing, e.g. "/*" and "*/"
+	Quotes       string    // characters that open a single-line string
+	MultiStrings []string  // delimiters of strings that can span lines, e.g. "`" or `"""`
+}
+
+func keywords(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	cLike = "if else for while do switch case default break continue return goto struct union enum typedef sizeof static const extern void int char long short float double unsigned signed true false NULL"
+
+	languages = map[string]*language{
+		"go": {Name: "Go", LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, MultiStrings: []string{"`"},
+			Keywords: keywords("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false iota")},
+		"js": {Name: "JavaScript", LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, MultiStrings: []string{"`"},
+			Keywords: keywords("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return static super switch this throw try typeof var void while yield null undefined true false interface type enum implements private public protected readonly")},
+		"python": {Name: "Python", LineComments: []string{"#"}, Quotes: `"'`, MultiStrings: []string{`"""`, "'''"},
+			Keywords: keywords("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self")},
+		"ruby": {Name: "Ruby", LineComments: []string{"#"}, Quotes: `"'`,
+			Keywords: keywords("alias and begin break case class def defined? do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true undef unless until when while yield require attr_accessor")},
+		"rust": {Name: "Rust", LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"`,
+			Keywords: keywords("as async await break const continue crate dyn else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while Some None Ok Err")},
+		"java": {Name: "Java", LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, MultiStrings: []string{`"""`},
+			Keywords: keywords("abstract boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long native new null package private protected public return short static super switch synchronized this throw throws try void volatile while true false var val fun when object data override")},
+		"c": {Name: "C", LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`,
+			Keywords: keywords(cLike + " class namespace template typename public private protected virtual override new delete using nullptr auto bool this throw try catch include define ifdef ifndef endif")},
+		"csharp": {Name: "C#", LineComments: []string{"//"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`,
+			Keywords: keywords(cLike + " abstract as base bool class decimal delegate event explicit finally foreach implicit in interface internal is lock namespace new null object operator out override params private protected public readonly ref sealed string this throw try using var virtual async await")},
+		"php": {Name: "PHP", LineComments: []string{"//", "#"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`,
+			Keywords: keywords("abstract and array as break case catch class const continue default do echo else elseif extends final for foreach function global if implements interface namespace new null private protected public return static switch throw trait try use var while true false")},
+		"shell": {Name: "Shell", LineComments: []string{"#"}, Quotes: `"'`,
+			Keywords: keywords("if then else elif fi case esac for while until do done in function return local export readonly echo exit set unset shift source")},
+		"sql": {Name: "SQL", LineComments: []string{"--"}, BlockComment: [2]string{"/*", "*/"}, Quotes: `'"`, IgnoreCase: true,
+			Keywords: keywords("select from where and or not insert into values update set delete create table alter drop index join left right inner outer on as group by order having limit offset union all distinct null is in like between case when then else end primary key foreign references default")},
+		"yaml": {Name: "YAML", LineComments: []string{"#"}, Quotes: `"'`, Keywords: keywords("true false null yes no on off")},
+		"toml": {Name: "TOML", LineComments: []string{"#"}, Quotes: `"'`, MultiStrings: []string{`"""`, "'''"}, Keywords: keywords("true false")},
+		"json": {Name: "JSON", Quotes: `"`, Keywords: keywords("true false null")},
+		"lua":  {Name: "Lua", LineComments: []string{"--"}, Quotes: `"'`, Keywords: keywords("and break do else elseif end false for function goto if in local nil not or repeat return then true until while")},
+		"css":  {Name: "CSS", BlockComment: [2]string{"/*", "*/"}, Quotes: `"'`, Keywords: keywords("important media import from to")},
+	}
+
+	// extensions maps file extensions, and a few well-known file names, to languages
+	extensions = map[string]string{
+		".go": "go", ".js": "js", ".jsx": "js", ".mjs": "js", ".cjs": "js", ".ts": "js", ".tsx": "js", ".vue": "js",
+		".py": "python", ".pyi": "python", ".rb": "ruby", ".rake": "ruby", ".rs": "rust",
+		".java": "java", ".kt": "java", ".kts": "java", ".scala": "java", ".swift": "java", ".dart": "java",
+		".c": "c", ".h": "c", ".cc": "c", ".cpp": "c", ".cxx": "c", ".hpp": "c", ".m": "c", ".cs": "csharp", ".php": "php",
+		".sh": "shell", ".bash": "shell", ".zsh": "shell", ".sql": "sql", ".yaml": "yaml", ".yml": "yaml",
+		".toml": "toml", ".json": "json", ".lua": "lua", ".css": "css", ".scss": "css",
+		"Makefile": "shell", "Dockerfile": "shell", "Gemfile": "ruby", "Rakefile": "ruby",
+	}
+)
+
+// languageFor picks the language of a file from its name, nil when unknown
+func languageFor(path string) *language {
+	base := filepath.Base(path)
+	if name, ok := extensions[base]; ok {
+		return languages[name]
+	}
+	return languages[extensions[strings.ToLower(filepath.Ext(base))]]
+}
+
+// lexState carries an open block comment or multi-line string from one line
+// to the next
+type lexState struct {
+	Close   string // delimiter that ends it
+	Comment bool
+}
+
+// highlighter colors the lines of one hunk, following the old and the new
+// version separately so comments and strings spanning lines come out right
+// on both sides
+type highlighter struct {
+	lang     *language
+	old, new lexState
+}
+
+func newHighlighter(f *File) *highlighter {
+	if cfg.NoSyntax {
+		return &highlighter{}
+	}
+	return &highlighter{lang: languageFor(f.NewPath)}
+}
+
+// line returns the segments of a line with their tokens
+func (hl *highlighter) line(l Line) []segment {
+	if hl.lang == nil {
+		return plainSegments(l.Text)
+	}
+	switch l.Kind {
+	case lineDeleted:
+		return hl.lang.tokenize(l.Text, &hl.old)
+	case lineAdded:
+		return hl.lang.tokenize(l.Text, &hl.new)
+	}
+	hl.lang.tokenize(l.Text, &hl.old)
+	return hl.lang.tokenize(l.Text, &hl.new)
+}
+
+// tokenize splits a line into tokens, starting inside whatever state left open
+func (lang *language) tokenize(text string, state *lexState) []segment {
+	var segs []segment
+	add := func(token int, s string) {
+		if n := len(segs); n > 0 && segs[n-1].Token == token {
+			segs[n-1].Text += s
+			return
+		}
+		segs = append(segs, segment{Text: s, Token: token})
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		// Inside a block comment or multi-line string
+		if state.Close != "" {
+			token := tokenString
+			if state.Comment {
+				token = tokenComment
+			}
+			end := strings.Index(rest, state.Close)
+			if end < 0 {
+				add(token, rest)
+				return segs
+			}
+			add(token, rest[:end+len(state.Close)])
+			i += end + len(state.Close)
+			*state = lexState{}
+			continue
+		}
+
+		if prefixAny(rest, lang.LineComments) != "" {
+			add(tokenComment, rest)
+			return segs
+		}
+		if open := lang.BlockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			*state = lexState{Close: lang.BlockComment[1], Comment: true}
+			add(tokenComment, open)
+			i += len(open)
+			continue
+		}
+		if open := prefixAny(rest, lang.MultiStrings); open != "" {
+			*state = lexState{Close: open}
+			add(tokenString, open)
+			i += len(open)
+			continue
+		}
+		if strings.ContainsRune(lang.Quotes, rune(rest[0])) {
+			end := stringEnd(rest)
+			add(tokenString, rest[:end])
+			i += end
+			continue
+		}
+
+		r := rune(rest[0])
+		switch {
+		case r >= '0' && r <= '9':
+			end := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) && r != '.' })
+			if end < 0 {
+				end = len(rest)
+			}
+			add(tokenNumber, rest[:end])
+			i += end
+		case isWordRune(r) || r >= 0x80:
+			end := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+			if end <= 0 {
+				end = len(rest)
+				if r >= 0x80 {
+					// A non-ASCII rune that is not a letter
+					_, size := firstRune(rest)
+					end = size
+				}
+			}
+			word := rest[:end]
+			key := word
+			if lang.IgnoreCase {
+				key = strings.ToLower(word)
+			}
+			if lang.Keywords[key] {
+				add(tokenKeyword, word)
+			} else {
+				add(tokenPlain, word)
+			}
+			i += end
+		default:
+			add(tokenPlain, rest[:1])
+			i++
+		}
+	}
+	return segs
+}
+
+// stringEnd finds the end of a quoted string at the start of s, after its
+// closing quote, or the end of the line when it is not closed
+func stringEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+func prefixAny(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(s string) (rune, int) {
+	for i, r := range s {
+		if i > 0 {
+			return r, i
+		}
+	}
+	return 0, len(s)
+}
+
+// overlay combines syntax tokens with the changed words of a word diff; both
+// cover the same text
+func overlay(tokens, words []segment) []segment {
+	tokens, words = append([]segment(nil), tokens...), append([]segment(nil), words...)
+	var out []segment
+	for len(tokens) > 0 && len(words) > 0 {
+		t, w := tokens[0], words[0]
+		n := min(len(t.Text), len(w.Text))
+		out = append(out, segment{Text: t.Text[:n], Token: t.Token, Changed: w.Changed})
+		tokens[0].Text, words[0].Text = t.Text[n:], w.Text[n:]
+		if tokens[0].Text == "" {
+			tokens = tokens[1:]
+		}
+		if words[0].Text == "" {
+			words = words[1:]
+		}
+	}
+	return out
+}
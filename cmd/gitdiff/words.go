@@ -0,0 +1,171 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tokenPattern splits a line into words, runs of whitespace and single
+// punctuation characters
+var tokenPattern = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+
+// maxWordDiffCells bounds the token table of a line pair; longer lines are
+// shown as changed as a whole
+const maxWordDiffCells = 200000
+
+// minWordSimilarity is the share of a line pair that must be unchanged for
+// the changed words to be worth highlighting
+const minWordSimilarity = 0.4
+
+// segment is a piece of a line; Changed marks the words that differ from
+// the paired line and Token is the kind of syntax token it is part of
+type segment struct {
+	Text    string
+	Changed bool
+	Token   int
+}
+
+// wordOp is a run of words that both lines share (lineContext) or that only
+// the old or the new line has
+type wordOp struct {
+	Kind byte
+	Text string
+}
+
+// wordDiff compares two lines word by word. It returns false when the lines
+// have too little in common to be shown as one changed line.
+func wordDiff(old, new string) ([]wordOp, bool) {
+	a := tokenPattern.FindAllString(old, -1)
+	b := tokenPattern.FindAllString(new, -1)
+	if len(a)*len(b) > maxWordDiffCells {
+		return nil, false
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []wordOp
+	common := 0
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			common += len(a[i])
+			ops = appendOp(ops, lineContext, a[i])
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = appendOp(ops, lineDeleted, a[i])
+			i++
+		default:
+			ops = appendOp(ops, lineAdded, b[j])
+			j++
+		}
+	}
+
+	longest := max(len(old), len(new))
+	if longest > 0 && float64(common)/float64(longest) < minWordSimilarity {
+		return nil, false
+	}
+	return ops, true
+}
+
+// appendOp adds text to the ops, merging it with the last one of the same kind
+func appendOp(ops []wordOp, kind byte, text string) []wordOp {
+	if n := len(ops); n > 0 && ops[n-1].Kind == kind {
+		ops[n-1].Text += text
+		return ops
+	}
+	return append(ops, wordOp{Kind: kind, Text: text})
+}
+
+// sideSegments is one side of a word diff: the old line for lineDeleted,
+// the new line for lineAdded
+func sideSegments(ops []wordOp, side byte) []segment {
+	var segs []segment
+	for _, op := range ops {
+		if op.Kind == lineContext || op.Kind == side {
+			segs = append(segs, segment{Text: op.Text, Changed: op.Kind == side})
+		}
+	}
+	return segs
+}
+
+// plainSegments is a whole line as one segment
+func plainSegments(text string) []segment {
+	return []segment{{Text: text}}
+}
+
+// carriageReturn stands in for a "\r" in a line, usually from CRLF line endings
+const carriageReturn = "␍"
+
+// expandTabs replaces tabs in the segments with spaces up to the next tab
+// stop, and carriage returns with a visible sign
+func expandTabs(segs []segment, width int) []segment {
+	column := 0
+	out := make([]segment, 0, len(segs))
+	for _, s := range segs {
+		var b strings.Builder
+		for _, r := range s.Text {
+			if r == '\r' {
+				// A carriage return would move the cursor; show it instead
+				b.WriteString(carriageReturn)
+				column++
+				continue
+			}
+			if r == '\t' {
+				n := width - column%width
+				b.WriteString(strings.Repeat(" ", n))
+				column += n
+				continue
+			}
+			b.WriteRune(r)
+			column++
+		}
+		s.Text = b.String()
+		out = append(out, s)
+	}
+	return out
+}
+
+// wrapSegments cuts the segments into rows at most width characters wide
+func wrapSegments(segs []segment, width int) [][]segment {
+	rows := [][]segment{nil}
+	used := 0
+	for _, s := range segs {
+		runes := []rune(s.Text)
+		for len(runes) > 0 {
+			if used == width {
+				rows = append(rows, nil)
+				used = 0
+			}
+			n := min(width-used, len(runes))
+			piece := s
+			piece.Text = string(runes[:n])
+			rows[len(rows)-1] = append(rows[len(rows)-1], piece)
+			runes = runes[n:]
+			used += n
+		}
+	}
+	return rows
+}
+
+// segmentsWidth is the number of characters in the segments
+func segmentsWidth(segs []segment) int {
+	n := 0
+	for _, s := range segs {
+		n += len([]rune(s.Text))
+	}
+	return n
+}
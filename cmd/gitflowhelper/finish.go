@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/amanmehtacode/GitNoob/internal/journal"
+)
+
+// step is one action of a finish plan. Steps already carried out by an
+// earlier, interrupted finish are marked done and skipped.
+type step struct {
+	Description string
+	Done        bool
+	Run         func() error
+}
+
+// finishBranch merges a branch into its targets, tags it and deletes it
+func finishBranch(k kind, name string) {
+	branch := resolveBranch(k, name)
+	version := strings.TrimPrefix(branch, k.Prefix)
+	rerun := k.command("finish") + " " + version
+
+	if dirty, _ := gitOutput("status", "--porcelain", "--untracked-files=no"); dirty != "" {
+		log.Fatal(red("You have uncommitted changes. Commit or stash them before finishing."))
+	}
+	for _, target := range k.Targets {
+		if !branchExists(target) {
+			log.Fatalf(red("Branch %s does not exist. Run gitflowhelper init first."), target)
+		}
+	}
+	// Once the branch is in its base, the base moving on is not a problem
+	if !isAncestor(branch, k.Base) {
+		if n := behindBy(branch, k.Base); n > 0 {
+			log.Fatalf(red("%s is %d commit(s) behind %s. Bring it up to date first, e.g.:\n  git checkout %s && git merge %s"),
+				branch, n, k.Base, branch, k.Base)
+		}
+	}
+
+	tag := ""
+	if k.Tagged {
+		tag = loadSettings().TagPrefix + version
+		if _, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err == nil && !isAncestor(branch, tag) {
+			log.Fatalf(red("Tag %s already exists on another commit"), tag)
+		}
+	}
+
+	steps := planFinish(k, branch, version, tag)
+	if runPlan(fmt.Sprintf("Finishing %s:", branch), steps, fmt.Sprintf("finish %s %s", k.Name, version), rerun) {
+		fmt.Println(green(fmt.Sprintf("✓ Finished %s", branch)))
+	}
+}
+
+// runPlan prints the steps, asks for confirmation and carries out the ones
+// not done yet, recording them for rollbackhelper. On failure it tells the
+// user to fix the problem and run rerun again. It reports whether every step
+// was carried out.
+func runPlan(title string, steps []step, description, rerun string) bool {
+	fmt.Println(yellow("→ " + title))
+	pending := 0
+	for i, s := range steps {
+		if s.Done {
+			fmt.Printf("  %d. %s %s\n", i+1, s.Description, green("(done)"))
+			continue
+		}
+		fmt.Printf("  %d. %s\n", i+1, s.Description)
+		pending++
+	}
+	if pending == 0 {
+		fmt.Println(green("✓ Nothing left to do."))
+		return false
+	}
+	if cfg.DryRun || !confirm("Go ahead?") {
+		return false
+	}
+
+	entry, err := journal.Begin("gitflowhelper", description)
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not record the operation: %v", err))
+	}
+	for _, s := range steps {
+		if s.Done {
+			continue
+		}
+		fmt.Println(yellow("→ " + s.Description))
+		if err := s.Run(); err != nil {
+			entry.Finish()
+			log.Printf(red("✗ %v"), err)
+			fmt.Printf("Fix the problem, then run %s again to carry on.\n", rerun)
+			os.Exit(1)
+		}
+	}
+	entry.Finish()
+	return true
+}
+
+// resolveBranch finds the branch to finish: the named one, or the current
+// branch when it has the kind's prefix
+func resolveBranch(k kind, name string) string {
+	if name == "" {
+		current := currentBranch()
+		if !strings.HasPrefix(current, k.Prefix) {
+			log.Fatalf(red("Not on a %s branch. Name the %s to finish."), k.Prefix+"*", k.Name)
+		}
+		return current
+	}
+	branch := k.Prefix + strings.TrimPrefix(name, k.Prefix)
+	if !branchExists(branch) {
+		log.Fatalf(red("Branch %s does not exist"), branch)
+	}
+	return branch
+}
+
+// planFinish lists the merges, tag, pushes and deletion that finish a branch
+func planFinish(k kind, branch, version, tag string) []step {
+	var steps []step
+	// As in git-flow, later targets merge the tag so they also get any
+	// conflict resolution made on the first one
+	source := branch
+	for i, target := range k.Targets {
+		target, from := target, source
+		_, err := gitOutput("rev-parse", "--verify", "--quiet", from)
+		steps = append(steps, step{
+			Description: fmt.Sprintf("Merge %s into %s", from, target),
+			Done:        err == nil && isAncestor(from, target),
+			Run: func() error {
+				if _, err := gitOutput("checkout", "--quiet", target); err != nil {
+					return fmt.Errorf("failed to check out %s: %v", target, err)
+				}
+				if err := gitPassthrough("merge", "--no-ff", "--no-edit", from); err != nil {
+					return fmt.Errorf("merging %s into %s failed. Resolve the conflicts and commit the merge", from, target)
+				}
+				return nil
+			},
+		})
+
+		if i == 0 && tag != "" {
+			source = tag
+			_, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/tags/"+tag)
+			steps = append(steps, step{
+				Description: fmt.Sprintf("Tag %s as %s", target, tag),
+				Done:        err == nil,
+				Run: func() error {
+					message := cfg.TagMessage
+					if message == "" {
+						message = fmt.Sprintf("%s%s %s", strings.ToUpper(k.Name[:1]), k.Name[1:], version)
+					}
+					if _, err := gitOutput("tag", "-a", tag, "-m", message, target); err != nil {
+						return fmt.Errorf("failed to tag %s: %v", target, err)
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	if cfg.Push {
+		if !hasRemote(cfg.Remote) {
+			log.Fatalf(red("Remote %s does not exist"), cfg.Remote)
+		}
+		refs := append([]string{}, k.Targets...)
+		if tag != "" {
+			refs = append(refs, tag)
+		}
+		steps = append(steps, step{
+			Description: fmt.Sprintf("Push %s to %s", strings.Join(refs, ", "), cfg.Remote),
+			Run: func() error {
+				return gitPassthrough(append([]string{"push", cfg.Remote}, refs...)...)
+			},
+		})
+		if _, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/remotes/"+cfg.Remote+"/"+branch); err == nil && !cfg.Keep {
+			steps = append(steps, step{
+				Description: fmt.Sprintf("Delete %s on %s", branch, cfg.Remote),
+				Run: func() error {
+					return gitPassthrough("push", cfg.Remote, "--delete", branch)
+				},
+			})
+		}
+	}
+
+	if !cfg.Keep {
+		last := k.Targets[len(k.Targets)-1]
+		steps = append(steps, step{
+			Description: fmt.Sprintf("Delete %s", branch),
+			Run: func() error {
+				if currentBranch() == branch {
+					if _, err := gitOutput("checkout", "--quiet", last); err != nil {
+						return fmt.Errorf("failed to check out %s: %v", last, err)
+					}
+				}
+				if _, err := gitOutput("branch", "-d", branch); err != nil {
+					return fmt.Errorf("failed to delete %s: %v", branch, err)
+				}
+				return nil
+			},
+		})
+	}
+	return steps
+}
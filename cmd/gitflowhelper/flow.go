@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/amanmehtacode/GitNoob/internal/journal"
+)
+
+// Branch kinds, also the names of their subcommands
+const (
+	kindFeature = "feature"
+	kindRelease = "release"
+	kindHotfix  = "hotfix"
+	kindTrunk   = "branch" // short-lived branch of the trunk workflow
+)
+
+// Workflows, chosen with gitflow.workflow
+const (
+	workflowGitflow = "gitflow" // feature, release and hotfix branches around develop
+	workflowTrunk   = "trunk"   // short-lived branches off main, GitHub flow
+)
+
+// Ways to finish a trunk branch, chosen with gitflow.trunk.finish
+const (
+	finishSquash = "squash" // squash-merge into main locally
+	finishPR     = "pr"     // push and open a pull request on GitHub
+)
+
+// settings are the workflow, branch names and prefixes, stored in git config
+// under the keys git-flow itself uses where it has one
+type settings struct {
+	Workflow    string
+	TrunkFinish string
+	Main        string
+	Develop     string
+	Prefixes    map[string]string // kind -> branch name prefix
+	TagPrefix   string
+}
+
+// kind describes where a branch starts and where it is merged when finished
+type kind struct {
+	Name    string
+	Prefix  string
+	Base    string   // branch it starts from and must stay up to date with
+	Targets []string // branches it is merged into on finish, in order
+	Tagged  bool     // finishing tags the first target with the version
+}
+
+// command returns the gitflowhelper command line that runs verb for this kind
+func (k kind) command(verb string) string {
+	if k.Name == kindTrunk {
+		return "gitflowhelper " + verb
+	}
+	return fmt.Sprintf("gitflowhelper %s %s", k.Name, verb)
+}
+
+// configValue reads a git config key, returning def only when it is unset
+func configValue(key, def string) string {
+	value, err := gitOutput("config", "--get", key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func loadSettings() settings {
+	main := configValue("gitflow.branch.main", configValue("gitflow.branch.master", ""))
+	if main == "" {
+		main = "main"
+		if !branchExists("main") && branchExists("master") {
+			main = "master"
+		}
+	}
+	return settings{
+		Workflow:    configValue("gitflow.workflow", workflowGitflow),
+		TrunkFinish: configValue("gitflow.trunk.finish", finishSquash),
+		Main:        main,
+		Develop:     configValue("gitflow.branch.develop", "develop"),
+		Prefixes: map[string]string{
+			kindFeature: configValue("gitflow.prefix.feature", "feature/"),
+			kindRelease: configValue("gitflow.prefix.release", "release/"),
+			kindHotfix:  configValue("gitflow.prefix.hotfix", "hotfix/"),
+		},
+		TagPrefix: configValue("gitflow.prefix.versiontag", "v"),
+	}
+}
+
+// requireWorkflow stops when the repository is set up for another workflow
+func requireWorkflow(workflow string) {
+	configured := loadSettings().Workflow
+	if configured == workflow {
+		return
+	}
+	hint := "gitflowhelper feature|release|hotfix start|finish"
+	if configured == workflowTrunk {
+		hint = "gitflowhelper start|sync|finish"
+	}
+	log.Fatalf(red("This repository uses the %s workflow (gitflow.workflow). Use %s, or run gitflowhelper init to switch."), configured, hint)
+}
+
+func lookupKind(name string) kind {
+	s := loadSettings()
+	k := kind{Name: name, Prefix: s.Prefixes[name]}
+	switch name {
+	case kindFeature:
+		k.Base, k.Targets = s.Develop, []string{s.Develop}
+	case kindRelease:
+		k.Base, k.Targets, k.Tagged = s.Develop, []string{s.Main, s.Develop}, true
+	case kindHotfix:
+		k.Base, k.Targets, k.Tagged = s.Main, []string{s.Main, s.Develop}, true
+		// As in git-flow, a hotfix goes into a release in progress instead of
+		// develop; the release brings it to develop when it is finished
+		if releases := listBranches(s.Prefixes[kindRelease]); len(releases) == 1 {
+			k.Targets[1] = releases[0]
+		}
+	}
+	return k
+}
+
+// listBranches returns the local branches starting with prefix
+func listBranches(prefix string) []string {
+	output, _ := gitOutput("for-each-ref", "--format=%(refname:short)", "refs/heads/"+prefix)
+	return nonEmptyLines(output)
+}
+
+// configPrompt is a git config key set by init; keys without a label are
+// asked for separately
+type configPrompt struct {
+	key, label string
+	value      *string
+}
+
+// initFlow asks for the workflow, branch names and prefixes and creates
+// develop if the git-flow workflow needs it
+func initFlow() {
+	s := loadSettings()
+	ask := func(prompt survey.Prompt, value *string) {
+		if cfg.Yes {
+			return
+		}
+		if err := survey.AskOne(prompt, value); err != nil {
+			log.Fatalf(red("Setup cancelled: %v"), err)
+		}
+	}
+	if cfg.Workflow != "" {
+		s.Workflow = cfg.Workflow
+	} else {
+		ask(&survey.Select{Message: "Workflow:", Options: []string{workflowGitflow, workflowTrunk}, Default: s.Workflow}, &s.Workflow)
+	}
+
+	values := []configPrompt{
+		{"gitflow.workflow", "", &s.Workflow},
+		{"gitflow.branch.main", "Production branch:", &s.Main},
+	}
+	if s.Workflow == workflowTrunk {
+		values = append(values, configPrompt{"gitflow.prefix.feature", "Branch prefix:", ptr(s.Prefixes[kindFeature])})
+	} else {
+		values = append(values, []configPrompt{
+			{"gitflow.branch.develop", "Development branch:", &s.Develop},
+			{"gitflow.prefix.feature", "Feature branch prefix:", ptr(s.Prefixes[kindFeature])},
+			{"gitflow.prefix.release", "Release branch prefix:", ptr(s.Prefixes[kindRelease])},
+			{"gitflow.prefix.hotfix", "Hotfix branch prefix:", ptr(s.Prefixes[kindHotfix])},
+			{"gitflow.prefix.versiontag", "Version tag prefix:", &s.TagPrefix},
+		}...)
+	}
+	for _, v := range values {
+		if v.label != "" {
+			ask(&survey.Input{Message: v.label, Default: *v.value}, v.value)
+		}
+		if _, err := gitOutput("config", v.key, *v.value); err != nil {
+			log.Fatalf(red("Failed to save %s: %v"), v.key, err)
+		}
+	}
+	if s.Workflow == workflowTrunk {
+		options := []string{finishSquash, finishPR}
+		ask(&survey.Select{Message: "Finish branches by:", Options: options, Default: s.TrunkFinish,
+			Description: func(value string, index int) string {
+				return []string{"squash-merging into main locally", "opening a pull request on GitHub"}[index]
+			}}, &s.TrunkFinish)
+		if _, err := gitOutput("config", "gitflow.trunk.finish", s.TrunkFinish); err != nil {
+			log.Fatalf(red("Failed to save gitflow.trunk.finish: %v"), err)
+		}
+	} else {
+		// git-flow AVH still reads the old key
+		gitOutput("config", "gitflow.branch.master", s.Main)
+	}
+
+	if !branchExists(s.Main) {
+		log.Fatalf(red("Branch %s does not exist yet. Make a first commit on it and run init again."), s.Main)
+	}
+	if s.Workflow == workflowTrunk {
+		fmt.Println(green(fmt.Sprintf("✓ Using the trunk workflow on %s, finishing branches by %s", s.Main, s.TrunkFinish)))
+		return
+	}
+	if !branchExists(s.Develop) {
+		if _, err := gitOutput("branch", s.Develop, s.Main); err != nil {
+			log.Fatalf(red("Failed to create %s: %v"), s.Develop, err)
+		}
+		fmt.Println(green(fmt.Sprintf("✓ Created %s from %s", s.Develop, s.Main)))
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Using git-flow with %s for production and %s for development", s.Main, s.Develop)))
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+// startBranch creates a branch of the given kind from a start point, usually
+// its base, and checks it out
+func startBranch(k kind, name, from string) {
+	name = strings.TrimPrefix(name, k.Prefix)
+	branch := k.Prefix + name
+	if _, err := gitOutput("check-ref-format", "--branch", branch); err != nil {
+		log.Fatalf(red("%q is not a valid branch name"), branch)
+	}
+	if branchExists(branch) {
+		log.Fatalf(red("Branch %s already exists"), branch)
+	}
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", from+"^{commit}"); err != nil {
+		log.Fatalf(red("Base branch %s does not exist. Run gitflowhelper init first."), from)
+	}
+	if k.Tagged {
+		if open := listBranches(k.Prefix); len(open) > 0 {
+			log.Fatalf(red("Finish %s before starting another %s"), strings.Join(open, ", "), k.Name)
+		}
+		tag := loadSettings().TagPrefix + name
+		if _, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err == nil {
+			log.Fatalf(red("Tag %s already exists"), tag)
+		}
+	}
+	if n := behindBy(from, from+"@{upstream}"); n > 0 {
+		fmt.Println(yellow(fmt.Sprintf("Warning: %s is %d commit(s) behind its upstream. Pull it first to start from the latest changes.", from, n)))
+	}
+
+	entry, err := journal.Begin("gitflowhelper", fmt.Sprintf("start %s %s", k.Name, name))
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not record the operation: %v", err))
+	}
+	defer entry.Finish()
+
+	// Starting from a remote-tracking branch must not make it the upstream
+	if _, err := gitOutput("checkout", "--no-track", "-b", branch, from); err != nil {
+		log.Fatalf(red("Failed to create %s: %v"), branch, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Created %s from %s and switched to it", branch, from)))
+	fmt.Printf("  Finish it with: %s %s\n", k.command("finish"), name)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// githubAPI is the base URL of the GitHub REST API
+var githubAPI = "https://api.github.com"
+
+// githubRemotePattern matches the owner and repository in SSH and HTTPS remote URLs
+var githubRemotePattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// githubRepo returns the owner and name of the GitHub repository behind a remote
+func githubRepo(remote string) (string, string, error) {
+	url, err := gitOutput("remote", "get-url", remote)
+	if err != nil {
+		return "", "", fmt.Errorf("remote %s does not exist", remote)
+	}
+	match := githubRemotePattern.FindStringSubmatch(url)
+	if match == nil {
+		return "", "", fmt.Errorf("remote %s (%s) is not a GitHub repository", remote, url)
+	}
+	return match[1], match[2], nil
+}
+
+func getGitHubCredentials() (string, string, error) {
+	usernameCmd := exec.Command("git", "config", "--global", "github.user")
+	usernameOutput, err := usernameCmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get GitHub username. Please set it using 'git config --global github.user YOUR_USERNAME'")
+	}
+	username := strings.TrimSpace(string(usernameOutput))
+
+	tokenCmd := exec.Command("git", "config", "--global", "github.token")
+	tokenOutput, err := tokenCmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get GitHub token: %w", err)
+	}
+	token := strings.TrimSpace(string(tokenOutput))
+
+	if token == "" {
+		return "", "", fmt.Errorf("GitHub token is empty. Please set it using 'git config --global github.token YOUR_TOKEN'")
+	}
+
+	return username, token, nil
+}
+
+type pullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// openPullRequest opens a pull request from branch into base, or returns the
+// one already open for the branch
+func openPullRequest(branch, base, title, body string) (pullRequest, error) {
+	var pr pullRequest
+	owner, repo, err := githubRepo(cfg.Remote)
+	if err != nil {
+		return pr, err
+	}
+	username, token, err := getGitHubCredentials()
+	if err != nil {
+		return pr, err
+	}
+
+	payload, _ := json.Marshal(map[string]string{"title": title, "body": body, "head": branch, "base": base})
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", githubAPI, owner, repo)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return pr, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(username, token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return pr, fmt.Errorf("failed to open the pull request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+			return pr, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return pr, nil
+	case http.StatusUnprocessableEntity:
+		// Most likely a pull request is already open for the branch
+		if existing, err := findPullRequest(owner, repo, branch, base, username, token); err == nil {
+			return existing, nil
+		}
+	}
+	var failure struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.NewDecoder(resp.Body).Decode(&failure)
+	details := failure.Message
+	for _, e := range failure.Errors {
+		details += ": " + e.Message
+	}
+	return pr, fmt.Errorf("failed to open the pull request: %s %s", resp.Status, details)
+}
+
+// findPullRequest returns the open pull request from branch into base
+func findPullRequest(owner, repo, branch, base, username, token string) (pullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls?state=open&head=%s:%s&base=%s", githubAPI, owner, repo, owner, branch, base)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return pullRequest{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(username, token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return pullRequest{}, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return pullRequest{}, fmt.Errorf("failed to list pull requests: %s", resp.Status)
+	}
+
+	var prs []pullRequest
+	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
+		return pullRequest{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(prs) == 0 {
+		return pullRequest{}, fmt.Errorf("no open pull request for %s", branch)
+	}
+	return prs[0], nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Yes         bool
+	DryRun      bool
+	Push        bool
+	Keep        bool
+	Remote      string
+	NoFetch     bool
+	TagMessage  string
+	Message     string
+	PR          bool
+	Squash      bool
+	Workflow    string
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "gitflowhelper",
+		Short: "Start and finish branches the git-flow or trunk-based way",
+		Long: `Start and finish branches the git-flow or trunk-based way.
+
+With git-flow, features branch off develop and are merged back into it.
+Releases branch off develop, hotfixes off main; both are merged into main and
+develop and tagged when finished.
+
+With the trunk workflow (GitHub flow), start creates a short-lived branch off
+the latest main, sync rebases it onto the latest main, and finish either
+squash-merges it into main locally or pushes it and opens a pull request.
+
+Finishing prints the plan first and refuses to go ahead while the branch is
+behind its base. If a merge conflicts, resolve it, commit and run the same
+finish command again to carry on.
+
+The workflow, branch names and prefixes are read from git config
+(gitflow.workflow, gitflow.branch.main, gitflow.branch.develop,
+gitflow.prefix.*, gitflow.trunk.finish); run gitflowhelper init to set them.`,
+	}
+
+	// Command-line flags
+	rootCmd.PersistentFlags().BoolVarP(&cfg.Yes, "yes", "y", false, "Do not ask for confirmation")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.DryRun, "dry-run", "n", false, "Only print the plan")
+	rootCmd.PersistentFlags().StringVarP(&cfg.Remote, "remote", "r", "origin", "Remote to push to")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.AddCommand(newInitCmd(), newTrunkStartCmd(), newTrunkSyncCmd(), newTrunkFinishCmd())
+	for _, k := range []string{kindFeature, kindRelease, kindHotfix} {
+		rootCmd.AddCommand(newKindCmd(k))
+	}
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+// newKindCmd builds the start and finish subcommands for one branch kind
+func newKindCmd(kindName string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   kindName,
+		Short: fmt.Sprintf("Start or finish a %s branch", kindName),
+	}
+
+	start := &cobra.Command{
+		Use:   "start <name>",
+		Short: fmt.Sprintf("Create a %s branch from its base", kindName),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireWorkflow(workflowGitflow)
+			k := lookupKind(kindName)
+			startBranch(k, args[0], k.Base)
+		},
+	}
+	if kindName != kindFeature {
+		start.Use = "start <version>"
+	}
+
+	finish := &cobra.Command{
+		Use:   "finish [name]",
+		Short: fmt.Sprintf("Merge a %s branch back and delete it (default: the current branch)", kindName),
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireWorkflow(workflowGitflow)
+			finishBranch(lookupKind(kindName), optionalArg(args))
+		},
+	}
+	finish.Flags().BoolVarP(&cfg.Push, "push", "p", false, "Push the merged branches and tag, and delete the remote branch")
+	finish.Flags().BoolVarP(&cfg.Keep, "keep", "k", false, "Keep the branch after finishing")
+	if kindName != kindFeature {
+		finish.Flags().StringVarP(&cfg.TagMessage, "message", "m", "", "Tag message (default: \"Release <version>\" or \"Hotfix <version>\")")
+	}
+
+	cmd.AddCommand(start, finish)
+	return cmd
+}
+
+func newInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Choose the workflow, the main and develop branches and the branch prefixes",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cfg.Workflow != "" && cfg.Workflow != workflowGitflow && cfg.Workflow != workflowTrunk {
+				log.Fatalf(red("Unknown workflow %q; use %s or %s"), cfg.Workflow, workflowGitflow, workflowTrunk)
+			}
+			initFlow()
+		},
+	}
+	cmd.Flags().StringVarP(&cfg.Workflow, "workflow", "w", "", "Workflow to use: gitflow or trunk (default: ask)")
+	return cmd
+}
+
+func newTrunkStartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "start <name>",
+		Short: "Trunk workflow: create a short-lived branch off the latest main",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireWorkflow(workflowTrunk)
+			startTrunk(args[0])
+		},
+	}
+	cmd.Flags().BoolVar(&cfg.NoFetch, "no-fetch", false, "Do not fetch main from the remote first")
+	return cmd
+}
+
+func newTrunkSyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync [name]",
+		Short: "Trunk workflow: rebase a branch onto the latest main (default: the current branch)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireWorkflow(workflowTrunk)
+			syncTrunk(optionalArg(args))
+		},
+	}
+	cmd.Flags().BoolVar(&cfg.NoFetch, "no-fetch", false, "Do not fetch main from the remote first")
+	return cmd
+}
+
+func newTrunkFinishCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "finish [name]",
+		Short: "Trunk workflow: squash-merge a branch into main or open a pull request (default: the current branch)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireWorkflow(workflowTrunk)
+			finishTrunk(optionalArg(args))
+		},
+	}
+	cmd.Flags().BoolVar(&cfg.PR, "pr", false, "Push the branch and open a pull request (default from gitflow.trunk.finish)")
+	cmd.Flags().BoolVar(&cfg.Squash, "squash", false, "Squash-merge into main locally (default from gitflow.trunk.finish)")
+	cmd.Flags().StringVarP(&cfg.Message, "message", "m", "", "Commit message or pull request title (default: from the branch's commits)")
+	cmd.Flags().BoolVarP(&cfg.Push, "push", "p", false, "After a squash-merge, push main and delete the remote branch")
+	cmd.Flags().BoolVarP(&cfg.Keep, "keep", "k", false, "Keep the branch after squash-merging")
+	cmd.Flags().BoolVar(&cfg.NoFetch, "no-fetch", false, "Do not fetch main from the remote first")
+	cmd.MarkFlagsMutuallyExclusive("pr", "squash")
+	return cmd
+}
+
+func optionalArg(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return ""
+}
+
+func confirm(message string) bool {
+	if cfg.Yes {
+		return true
+	}
+	answer := false
+	if err := survey.AskOne(&survey.Confirm{Message: message}, &answer); err != nil {
+		return false
+	}
+	return answer
+}
+
+// branchExists reports whether a local branch exists
+func branchExists(name string) bool {
+	_, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
+}
+
+// isAncestor reports whether commit a is reachable from b
+func isAncestor(a, b string) bool {
+	return exec.Command("git", "merge-base", "--is-ancestor", a, b).Run() == nil
+}
+
+// behindBy counts the commits on base that branch does not have
+func behindBy(branch, base string) int {
+	count, _ := gitOutput("rev-list", "--count", branch+".."+base)
+	n := 0
+	fmt.Sscanf(count, "%d", &n)
+	return n
+}
+
+func currentBranch() string {
+	branch, _ := gitOutput("symbolic-ref", "--quiet", "--short", "HEAD")
+	return branch
+}
+
+func hasRemote(name string) bool {
+	_, err := gitOutput("remote", "get-url", name)
+	return err == nil
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// gitOutput runs a git command and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logVerbose("git " + strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitPassthrough runs a git command attached to the terminal
+func gitPassthrough(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	logVerbose("git " + strings.Join(args, " "))
+	return cmd.Run()
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/amanmehtacode/GitNoob/internal/journal"
+	"github.com/amanmehtacode/GitNoob/internal/squash"
+)
+
+// trunkKind is the short-lived branch of the trunk workflow, started from and
+// finished into main
+func trunkKind() kind {
+	s := loadSettings()
+	return kind{Name: kindTrunk, Prefix: s.Prefixes[kindFeature], Base: s.Main, Targets: []string{s.Main}}
+}
+
+// latestMain fetches main and returns the most up-to-date ref for it: the
+// remote-tracking branch when there is one, otherwise the local branch
+func latestMain(k kind) string {
+	if !hasRemote(cfg.Remote) {
+		return k.Base
+	}
+	if !cfg.NoFetch {
+		fmt.Println(yellow(fmt.Sprintf("→ Fetching %s from %s...", k.Base, cfg.Remote)))
+		if _, err := gitOutput("fetch", "--quiet", cfg.Remote, k.Base); err != nil {
+			fmt.Println(yellow(fmt.Sprintf("Warning: could not fetch %s, using what is known locally: %v", k.Base, err)))
+		}
+	}
+	remote := cfg.Remote + "/" + k.Base
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/remotes/"+remote); err != nil {
+		return k.Base
+	}
+	return remote
+}
+
+// fastForward moves a local branch that is not checked out up to ref, when
+// that needs no merge
+func fastForward(branch, ref string) {
+	if ref == branch || currentBranch() == branch || !isAncestor(branch, ref) {
+		return
+	}
+	old, _ := gitOutput("rev-parse", branch)
+	if _, err := gitOutput("update-ref", "refs/heads/"+branch, ref, old); err != nil {
+		logVerbose(fmt.Sprintf("Could not fast-forward %s: %v", branch, err))
+	}
+}
+
+// startTrunk creates a short-lived branch off the latest main
+func startTrunk(name string) {
+	k := trunkKind()
+	from := latestMain(k)
+	fastForward(k.Base, from)
+	startBranch(k, name, from)
+}
+
+// syncTrunk rebases a branch onto the latest main
+func syncTrunk(name string) {
+	k := trunkKind()
+	branch := resolveBranch(k, name)
+	if dirty, _ := gitOutput("status", "--porcelain", "--untracked-files=no"); dirty != "" {
+		log.Fatal(red("You have uncommitted changes. Commit or stash them before syncing."))
+	}
+	from := latestMain(k)
+	fastForward(k.Base, from)
+	if isAncestor(from, branch) {
+		fmt.Println(green(fmt.Sprintf("✓ %s is already up to date with %s", branch, from)))
+		return
+	}
+
+	entry, err := journal.Begin("gitflowhelper", "sync "+branch)
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not record the operation: %v", err))
+	}
+	fmt.Println(yellow(fmt.Sprintf("→ Rebasing %s onto %s...", branch, from)))
+	err = gitPassthrough("rebase", from, branch)
+	entry.Finish()
+	if err != nil {
+		log.Print(red("✗ The rebase stopped on a conflict."))
+		fmt.Println("Resolve the conflicts, git add the files and run git rebase --continue,")
+		fmt.Println("or run git rebase --abort to put the branch back as it was.")
+		os.Exit(1)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ %s is up to date with %s", branch, from)))
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", branch+"@{upstream}"); err == nil {
+		fmt.Println(yellow("The branch was pushed before; update it with: git push --force-with-lease"))
+	}
+}
+
+// finishTrunk squash-merges a branch into main or opens a pull request for it
+func finishTrunk(name string) {
+	k := trunkKind()
+	branch := resolveBranch(k, name)
+	if dirty, _ := gitOutput("status", "--porcelain", "--untracked-files=no"); dirty != "" {
+		log.Fatal(red("You have uncommitted changes. Commit or stash them before finishing."))
+	}
+	mode := loadSettings().TrunkFinish
+	switch {
+	case cfg.PR:
+		mode = finishPR
+	case cfg.Squash:
+		mode = finishSquash
+	}
+
+	from := latestMain(k)
+	title, body := describeBranch(branch, from)
+	rerun := k.command("finish") + " " + strings.TrimPrefix(branch, k.Prefix)
+
+	if mode == finishPR {
+		if _, _, err := githubRepo(cfg.Remote); err != nil {
+			log.Fatalf(red("Cannot open a pull request: %v"), err)
+		}
+		if _, _, err := getGitHubCredentials(); err != nil {
+			log.Fatalf(red("Cannot open a pull request: %v"), err)
+		}
+		if n := behindBy(branch, from); n > 0 {
+			fmt.Println(yellow(fmt.Sprintf("Warning: %s is %d commit(s) behind %s. Consider gitflowhelper sync first.", branch, n, from)))
+		}
+		steps := planPullRequest(k, branch, title, body)
+		runPlan(fmt.Sprintf("Opening a pull request for %s:", branch), steps, "open a pull request for "+branch, rerun)
+		return
+	}
+
+	landed := squash.Merged(branch, k.Base)
+	if !landed {
+		if n := behindBy(branch, from); n > 0 {
+			log.Fatalf(red("%s is %d commit(s) behind %s. Bring it up to date first with: gitflowhelper sync"), branch, n, from)
+		}
+		if behindBy(from, branch) == 0 {
+			log.Fatalf(red("%s has no commits of its own to merge"), branch)
+		}
+	}
+	steps := planSquash(k, branch, from, title, body, landed)
+	if runPlan(fmt.Sprintf("Finishing %s:", branch), steps, "finish "+branch, rerun) {
+		fmt.Println(green(fmt.Sprintf("✓ Squash-merged %s into %s", branch, k.Base)))
+	}
+}
+
+// describeBranch suggests a title and body for the squashed commit or pull
+// request: the only commit's message, or the branch name and a list of its
+// commit subjects
+func describeBranch(branch, base string) (string, string) {
+	output, _ := gitOutput("log", "--reverse", "--format=%s", base+".."+branch)
+	subjects := nonEmptyLines(output)
+	if len(subjects) == 1 {
+		message, _ := gitOutput("log", "-1", "--format=%B", branch)
+		title, body, _ := strings.Cut(message, "\n")
+		if cfg.Message != "" {
+			title = cfg.Message
+		}
+		return title, strings.TrimSpace(body)
+	}
+
+	title := cfg.Message
+	if title == "" {
+		name := strings.NewReplacer("-", " ", "_", " ").Replace(branch[strings.LastIndex(branch, "/")+1:])
+		title = strings.ToUpper(name[:1]) + name[1:]
+	}
+	var body []string
+	for _, subject := range subjects {
+		body = append(body, "- "+subject)
+	}
+	return title, strings.Join(body, "\n")
+}
+
+// planSquash lists the steps that squash-merge a branch into main
+func planSquash(k kind, branch, from, title, body string, landed bool) []step {
+	main := k.Base
+	steps := []step{
+		{
+			Description: fmt.Sprintf("Update %s to %s", main, from),
+			Done:        isAncestor(from, main),
+			Run: func() error {
+				if _, err := gitOutput("checkout", "--quiet", main); err != nil {
+					return fmt.Errorf("failed to check out %s: %v", main, err)
+				}
+				if _, err := gitOutput("merge", "--ff-only", from); err != nil {
+					return fmt.Errorf("%s has diverged from %s; reconcile them first: %v", main, from, err)
+				}
+				return nil
+			},
+		},
+		{
+			Description: fmt.Sprintf("Squash-merge %s into %s as %q", branch, main, title),
+			Done:        landed,
+			Run: func() error {
+				if _, err := gitOutput("checkout", "--quiet", main); err != nil {
+					return fmt.Errorf("failed to check out %s: %v", main, err)
+				}
+				if err := gitPassthrough("merge", "--squash", branch); err != nil {
+					return fmt.Errorf("squash-merging %s into %s failed. Resolve the conflicts and commit", branch, main)
+				}
+				message := title
+				if body != "" {
+					message += "\n\n" + body
+				}
+				if _, err := gitOutput("commit", "--quiet", "-m", message); err != nil {
+					return fmt.Errorf("failed to commit the squashed changes: %v", err)
+				}
+				return nil
+			},
+		},
+	}
+
+	if cfg.Push {
+		if !hasRemote(cfg.Remote) {
+			log.Fatalf(red("Remote %s does not exist"), cfg.Remote)
+		}
+		steps = append(steps, step{
+			Description: fmt.Sprintf("Push %s to %s", main, cfg.Remote),
+			Run: func() error {
+				return gitPassthrough("push", cfg.Remote, main)
+			},
+		})
+		if _, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/remotes/"+cfg.Remote+"/"+branch); err == nil && !cfg.Keep {
+			steps = append(steps, step{
+				Description: fmt.Sprintf("Delete %s on %s", branch, cfg.Remote),
+				Run: func() error {
+					return gitPassthrough("push", cfg.Remote, "--delete", branch)
+				},
+			})
+		}
+	}
+
+	if !cfg.Keep {
+		steps = append(steps, step{
+			Description: fmt.Sprintf("Delete %s", branch),
+			Run: func() error {
+				if currentBranch() == branch {
+					if _, err := gitOutput("checkout", "--quiet", main); err != nil {
+						return fmt.Errorf("failed to check out %s: %v", main, err)
+					}
+				}
+				// A squash merge leaves the branch unmerged as far as git can tell
+				if !squash.Merged(branch, main) {
+					return fmt.Errorf("%s is not in %s yet; not deleting it", branch, main)
+				}
+				if _, err := gitOutput("branch", "-D", branch); err != nil {
+					return fmt.Errorf("failed to delete %s: %v", branch, err)
+				}
+				return nil
+			},
+		})
+	}
+	return steps
+}
+
+// planPullRequest lists the steps that push a branch and open a pull request
+func planPullRequest(k kind, branch, title, body string) []step {
+	local, _ := gitOutput("rev-parse", branch)
+	remote, _ := gitOutput("rev-parse", "--verify", "--quiet", "refs/remotes/"+cfg.Remote+"/"+branch)
+	return []step{
+		{
+			Description: fmt.Sprintf("Push %s to %s", branch, cfg.Remote),
+			Done:        local == remote,
+			Run: func() error {
+				return gitPassthrough("push", "--set-upstream", cfg.Remote, branch)
+			},
+		},
+		{
+			Description: fmt.Sprintf("Open a pull request from %s into %s titled %q", branch, k.Base, title),
+			Run: func() error {
+				pr, err := openPullRequest(branch, k.Base, title, body)
+				if err != nil {
+					return err
+				}
+				fmt.Println(green(fmt.Sprintf("✓ Pull request #%d: %s", pr.Number, pr.HTMLURL)))
+				return nil
+			},
+		},
+	}
+}
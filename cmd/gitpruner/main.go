@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Prune            bool
+	Yes              bool
+	Aggressive       bool
+	Top              int
+	LFSThresholdMB   float64
+	ReflogExpire     string
+	UnreachableAfter string
+	VerboseMode      bool
+}
+
+// Global variables
+var (
+	cfg Config
+	s   *spinner.Spinner
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "gitpruner",
+		Short: "Report what takes up space in a repository and shrink it",
+		Long: `Report what takes up space in a repository and shrink it.
+
+By default only the report is printed. --prune then prunes stale
+remote-tracking refs, expires old reflog entries and runs git gc, after asking
+first: expired reflog entries are gone for good, and rollbackhelper's undo
+from the reflog can no longer go back past them.`,
+		Run: gitPruner,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().BoolVarP(&cfg.Prune, "prune", "p", false, "After the report, prune remotes, expire reflogs and run gc")
+	rootCmd.Flags().BoolVarP(&cfg.Yes, "yes", "y", false, "Do not ask for confirmation before pruning")
+	rootCmd.Flags().BoolVarP(&cfg.Aggressive, "aggressive", "a", false, "Recompute all deltas when repacking (slow, smallest result)")
+	rootCmd.Flags().IntVarP(&cfg.Top, "top", "t", 10, "Number of largest blobs in history to list")
+	rootCmd.Flags().Float64Var(&cfg.LFSThresholdMB, "lfs-threshold", 1, "Suggest Git LFS for tracked files at least this many MB")
+	rootCmd.Flags().StringVar(&cfg.ReflogExpire, "reflog-expire", "90.days.ago", "Expire reflog entries older than this")
+	rootCmd.Flags().StringVar(&cfg.UnreachableAfter, "prune-expire", "2.weeks.ago", "Expire unreachable reflog entries and prune loose objects older than this")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func gitPruner(cmd *cobra.Command, args []string) {
+	if _, err := gitOutput("rev-parse", "--git-dir"); err != nil {
+		log.Fatal(red("Not inside a Git repository"))
+	}
+
+	before, err := measure()
+	if err != nil {
+		log.Fatalf(red("Failed to measure repository: %v"), err)
+	}
+	printSizes(before)
+	printLargestBlobs()
+	printLFSCandidates()
+
+	if !cfg.Prune {
+		fmt.Println()
+		fmt.Println("Run with --prune to prune stale remote refs, expire reflogs and run gc.")
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(yellow(fmt.Sprintf("This expires reflog entries older than %s (unreachable ones older than %s)", cfg.ReflogExpire, cfg.UnreachableAfter)))
+	fmt.Println(yellow("and deletes unreachable objects. rollbackhelper cannot undo past what is expired."))
+	if !confirm("Prune the repository?") {
+		fmt.Println("Nothing was changed.")
+		return
+	}
+	pruneRemotes()
+	runStep("Expiring reflogs", "reflog", "expire", "--all",
+		"--expire="+cfg.ReflogExpire, "--expire-unreachable="+cfg.UnreachableAfter)
+	if cfg.Aggressive {
+		runStep("Repacking all objects", "repack", "-a", "-d", "-f", "--depth=50", "--window=250", "--write-bitmap-index")
+	}
+	runStep("Running garbage collection", "gc", "--quiet", "--prune="+cfg.UnreachableAfter)
+
+	after, err := measure()
+	if err != nil {
+		log.Fatalf(red("Failed to measure repository: %v"), err)
+	}
+	printSavings(before, after)
+}
+
+// pruneRemotes deletes remote-tracking refs whose branches no longer exist
+func pruneRemotes() {
+	output, _ := gitOutput("remote")
+	for _, remote := range strings.Fields(output) {
+		startSpinner(fmt.Sprintf("Pruning stale refs from %s", remote))
+		out, err := exec.Command("git", "remote", "prune", remote).CombinedOutput()
+		stopSpinner()
+		if err != nil {
+			logError(fmt.Sprintf("Failed to prune %s", remote), err)
+			continue
+		}
+		pruned := 0
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.Contains(line, "[pruned]") {
+				pruned++
+				logVerbose(strings.TrimSpace(line))
+			}
+		}
+		fmt.Println(green(fmt.Sprintf("✓ Pruned %d stale ref(s) from %s", pruned, remote)))
+	}
+}
+
+// confirm asks a yes/no question unless --yes was given
+func confirm(message string) bool {
+	if cfg.Yes {
+		return true
+	}
+	answer := false
+	if err := survey.AskOne(&survey.Confirm{Message: message}, &answer); err != nil {
+		return false
+	}
+	return answer
+}
+
+// runStep runs one maintenance command behind a spinner
+func runStep(message string, args ...string) {
+	startSpinner(message)
+	_, err := gitOutput(args...)
+	stopSpinner()
+	if err != nil {
+		log.Fatalf(red("%s failed: %v"), message, err)
+	}
+	fmt.Println(green("✓ " + message))
+}
+
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
+
+func logError(message string, err error) {
+	log.Printf("%s %s: %v", red("✗"), message, err)
+}
+
+func startSpinner(message string) {
+	s = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + message
+	s.Start()
+}
+
+func stopSpinner() {
+	s.Stop()
+}
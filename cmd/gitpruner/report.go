@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// repoSize is a snapshot of where a repository's disk space goes
+type repoSize struct {
+	LooseObjects  int64
+	LooseBytes    int64
+	PackedObjects int64
+	Packs         int64
+	PackBytes     int64
+	GarbageBytes  int64
+	PrunePackable int64
+	GitDirBytes   int64
+}
+
+// measure reads object counts from git count-objects and sums the git directory
+func measure() (repoSize, error) {
+	output, err := gitOutput("count-objects", "-v")
+	if err != nil {
+		return repoSize{}, err
+	}
+	values := map[string]int64{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		values[key] = n
+	}
+
+	gitDir, err := gitOutput("rev-parse", "--git-common-dir")
+	if err != nil {
+		return repoSize{}, err
+	}
+	total, err := dirSize(gitDir)
+	if err != nil {
+		return repoSize{}, err
+	}
+
+	// count-objects reports sizes in KiB
+	return repoSize{
+		LooseObjects:  values["count"],
+		LooseBytes:    values["size"] * 1024,
+		PackedObjects: values["in-pack"],
+		Packs:         values["packs"],
+		PackBytes:     values["size-pack"] * 1024,
+		GarbageBytes:  values["size-garbage"] * 1024,
+		PrunePackable: values["prune-packable"],
+		GitDirBytes:   total,
+	}, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+func printSizes(size repoSize) {
+	fmt.Println(yellow("→ Repository size"))
+	fmt.Printf("  %-16s %10s  (%d objects)\n", "Loose objects", humanSize(size.LooseBytes), size.LooseObjects)
+	fmt.Printf("  %-16s %10s  (%d objects in %d pack(s))\n", "Packed objects", humanSize(size.PackBytes), size.PackedObjects, size.Packs)
+	if size.GarbageBytes > 0 {
+		fmt.Printf("  %-16s %10s\n", "Garbage", humanSize(size.GarbageBytes))
+	}
+	if size.PrunePackable > 0 {
+		fmt.Printf("  %-16s %10d  loose objects already in packs\n", "Redundant", size.PrunePackable)
+	}
+	fmt.Printf("  %-16s %10s\n", "Git directory", humanSize(size.GitDirBytes))
+}
+
+func printSavings(before, after repoSize) {
+	fmt.Println()
+	fmt.Println(yellow("→ Before / after"))
+	row := func(name string, b, a int64) {
+		fmt.Printf("  %-16s %10s → %-10s\n", name, humanSize(b), humanSize(a))
+	}
+	row("Loose objects", before.LooseBytes, after.LooseBytes)
+	row("Packed objects", before.PackBytes, after.PackBytes)
+	row("Git directory", before.GitDirBytes, after.GitDirBytes)
+
+	saved := before.GitDirBytes - after.GitDirBytes
+	if saved > 0 {
+		fmt.Println(green(fmt.Sprintf("✓ Saved %s (%.1f%%). 🧹", humanSize(saved), 100*float64(saved)/float64(before.GitDirBytes))))
+	} else {
+		fmt.Println(green("✓ Repository was already compact."))
+	}
+}
+
+// blob is a file version stored in the repository
+type blob struct {
+	Hash     string
+	Path     string
+	Size     int64
+	DiskSize int64
+	InHead   bool
+}
+
+// largestBlobs lists every blob reachable from any ref, largest first
+func largestBlobs() ([]blob, error) {
+	objects, err := gitOutput("rev-list", "--objects", "--all")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch-check=%(objecttype) %(objectname) %(objectsize) %(objectsize:disk) %(rest)")
+	cmd.Stdin = strings.NewReader(objects + "\n")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	head := headBlobs()
+	var blobs []blob
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 5)
+		if len(fields) < 5 || fields[0] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[2], 10, 64)
+		disk, _ := strconv.ParseInt(fields[3], 10, 64)
+		blobs = append(blobs, blob{Hash: fields[1], Size: size, DiskSize: disk, Path: fields[4], InHead: head[fields[1]]})
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].Size > blobs[j].Size })
+	return blobs, nil
+}
+
+// headBlobs returns the set of blob hashes in the current checkout's tree
+func headBlobs() map[string]bool {
+	blobs := map[string]bool{}
+	output, _ := gitOutput("ls-tree", "-r", "--full-tree", "HEAD")
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == "blob" {
+			blobs[fields[2]] = true
+		}
+	}
+	return blobs
+}
+
+func printLargestBlobs() {
+	if cfg.Top <= 0 {
+		return
+	}
+	startSpinner("Scanning history for large files")
+	blobs, err := largestBlobs()
+	stopSpinner()
+	if err != nil {
+		logError("Failed to scan history", err)
+		return
+	}
+	if len(blobs) == 0 {
+		return
+	}
+	if len(blobs) > cfg.Top {
+		blobs = blobs[:cfg.Top]
+	}
+
+	fmt.Println()
+	fmt.Println(yellow(fmt.Sprintf("→ Largest %d file version(s) in history", len(blobs))))
+	historyOnly := false
+	for _, b := range blobs {
+		where := "in HEAD"
+		if !b.InHead {
+			where = red("history only")
+			historyOnly = true
+		}
+		fmt.Printf("  %10s  %10s on disk  %s  %s  (%s)\n", humanSize(b.Size), humanSize(b.DiskSize), b.Hash[:10], b.Path, where)
+	}
+	if historyOnly {
+		fmt.Println(yellow("  Files only in history can only be removed by rewriting it, e.g. git filter-repo --path <file> --invert-paths"))
+	}
+}
+
+// printLFSCandidates lists tracked files above the threshold that are not yet in LFS
+func printLFSCandidates() {
+	threshold := int64(cfg.LFSThresholdMB * 1024 * 1024)
+	if threshold <= 0 {
+		return
+	}
+	output, err := gitOutput("ls-tree", "-r", "-l", "--full-tree", "HEAD")
+	if err != nil {
+		return
+	}
+	top, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return
+	}
+
+	var candidates []blob
+	for _, line := range strings.Split(output, "\n") {
+		meta, path, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		if size >= threshold && !inLFS(top, path) {
+			candidates = append(candidates, blob{Hash: fields[2], Path: path, Size: size})
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Size > candidates[j].Size })
+
+	fmt.Println()
+	fmt.Println(yellow(fmt.Sprintf("→ %d tracked file(s) over %s could move to Git LFS", len(candidates), humanSize(threshold))))
+	patterns := map[string]bool{}
+	for _, c := range candidates {
+		fmt.Printf("  %10s  %s\n", humanSize(c.Size), c.Path)
+		if ext := filepath.Ext(c.Path); ext != "" {
+			patterns["*"+ext] = true
+		} else {
+			patterns[c.Path] = true
+		}
+	}
+	var track []string
+	for pattern := range patterns {
+		track = append(track, fmt.Sprintf("%q", pattern))
+	}
+	sort.Strings(track)
+	fmt.Printf("  Suggested: git lfs track %s && git lfs migrate import --include=%s\n",
+		strings.Join(track, " "), strings.Join(track, ","))
+}
+
+// inLFS reports whether a path, relative to the top of the work tree, is stored in LFS
+func inLFS(top, path string) bool {
+	cmd := exec.Command("git", "check-attr", "filter", "--", path)
+	cmd.Dir = top
+	output, _ := cmd.Output()
+	return strings.HasSuffix(strings.TrimSpace(string(output)), ": lfs")
+}
+
+func humanSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
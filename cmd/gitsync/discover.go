@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultIgnores are directories never worth descending into when looking for repositories
+var defaultIgnores = []string{"node_modules", "vendor", ".venv", "venv", "target", "dist", "build", ".cache"}
+
+// repo is a repository found on disk
+type repo struct {
+	Path      string // absolute path of the working tree
+	Rel       string // path relative to the directory that was searched
+	CommonDir string // shared git directory, the same for all worktrees of a repository
+	Worktree  bool   // a linked worktree rather than the main checkout
+	Submodule bool
+}
+
+// discoverRepos walks root looking for working trees, descending at most
+// depth directories (0 means unlimited) and skipping ignored directory names
+func discoverRepos(root string, depth int, ignore []string, includeSubmodules bool) ([]repo, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []repo
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than aborting the whole walk
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if path != root && isIgnored(d.Name(), ignore) {
+			return filepath.SkipDir
+		}
+
+		if r, ok := inspectRepo(root, path); ok && (!r.Submodule || includeSubmodules) {
+			repos = append(repos, r)
+		}
+
+		if depth > 0 && path != root && strings.Count(relPath(root, path), string(filepath.Separator)) >= depth-1 {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Rel < repos[j].Rel })
+	return repos, err
+}
+
+// inspectRepo reports whether dir is the top of a working tree and what kind it is
+func inspectRepo(root, dir string) (repo, bool) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return repo{}, false
+	}
+
+	r := repo{Path: dir, Rel: relPath(root, dir), CommonDir: dotGit}
+	if info.IsDir() {
+		return r, true
+	}
+
+	// A .git file points at the real git directory: worktrees live under
+	// <common>/worktrees/<name>, submodules under <superproject>/.git/modules/<name>
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return repo{}, false
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(content)), "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	gitDir = filepath.Clean(gitDir)
+
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		r.CommonDir = filepath.Clean(commonDir)
+		r.Worktree = true
+		return r, true
+	}
+
+	r.CommonDir = gitDir
+	r.Submodule = strings.Contains(filepath.ToSlash(gitDir), "/.git/modules/")
+	return r, true
+}
+
+// skipUpdatedSubmodules leaves out the submodules whose superproject was
+// found too: syncing the superproject updates them with git submodule update,
+// and syncing them as well would run two git commands on the same checkout
+func skipUpdatedSubmodules(repos []repo) []repo {
+	found := map[string]bool{}
+	for _, r := range repos {
+		if !r.Submodule {
+			found[r.CommonDir] = true
+		}
+	}
+	var kept []repo
+	for _, r := range repos {
+		if r.Submodule && found[superprojectGitDir(r.CommonDir)] {
+			logVerbose(fmt.Sprintf("Skipping submodule %s; its superproject updates it", r.Rel))
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// superprojectGitDir is the .git directory of the top superproject, from a
+// submodule git directory under <superproject>/.git/modules/
+func superprojectGitDir(gitDir string) string {
+	slashed := filepath.ToSlash(gitDir)
+	if i := strings.Index(slashed, "/.git/modules/"); i >= 0 {
+		return filepath.FromSlash(slashed[:i+len("/.git")])
+	}
+	return ""
+}
+
+// isIgnored matches a directory name against the ignore patterns
+func isIgnored(name string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func relPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
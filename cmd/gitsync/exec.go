@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execFilters []string
+	execQuiet   bool
+)
+
+func newExecCmd() *cobra.Command {
+	execCmd := &cobra.Command{
+		Use:   "exec [directory...] -- <command> [args...]",
+		Short: "Run a command in every discovered repository concurrently",
+		Long: `Run a command in every repository, prefixing each output line with the repository name.
+
+A single quoted argument is run through sh -c, so pipes and && work:
+  gitsync exec -- 'go mod tidy && git status --short'
+
+Filters can be combined and must all match:
+  --filter branch=main   current branch (glob patterns allowed)
+  --filter dirty         has uncommitted changes
+  --filter clean         has no uncommitted changes
+  --filter group=backend manifest group (needs --manifest)`,
+		Run: execAll,
+	}
+	execCmd.Flags().StringArrayVarP(&execFilters, "filter", "f", nil, "Only run in repositories matching this filter (repeatable)")
+	execCmd.Flags().BoolVarP(&execQuiet, "quiet", "q", false, "Hide command output and only print the summary")
+	return execCmd
+}
+
+// execTarget is a repository the command will run in
+type execTarget struct {
+	Name   string
+	Path   string
+	Groups []string
+}
+
+// execResult is the outcome of running the command in one repository
+type execResult struct {
+	Target   execTarget
+	ExitCode int
+	Err      error
+	Duration time.Duration
+}
+
+func execAll(cmd *cobra.Command, args []string) {
+	dash := cmd.ArgsLenAtDash()
+	if dash == -1 || dash == len(args) {
+		log.Fatal(red("No command given. Usage: gitsync exec [directory...] -- <command> [args...]"))
+	}
+	dirs, command := args[:dash], args[dash:]
+
+	targets := execTargets(dirs)
+	targets, err := filterTargets(targets, execFilters)
+	if err != nil {
+		log.Fatal(red(err.Error()))
+	}
+	if len(targets) == 0 {
+		fmt.Println(yellow("No repositories match."))
+		return
+	}
+	fmt.Println(yellow(fmt.Sprintf("→ Running '%s' in %d repositories...", strings.Join(command, " "), len(targets))))
+
+	width := 0
+	for _, t := range targets {
+		if len(t.Name) > width {
+			width = len(t.Name)
+		}
+	}
+
+	var out sync.Mutex
+	results := make([]execResult, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	jobs := cfg.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				prefix := prefixColors[i%len(prefixColors)](fmt.Sprintf("%-*s │ ", width, targets[i].Name))
+				results[i] = runIn(targets[i], command, &prefixWriter{prefix: prefix, mu: &out})
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if printExecSummary(results) {
+		os.Exit(1)
+	}
+}
+
+var prefixColors = []func(a ...interface{}) string{
+	color.New(color.FgCyan).SprintFunc(),
+	color.New(color.FgMagenta).SprintFunc(),
+	color.New(color.FgBlue).SprintFunc(),
+	color.New(color.FgGreen).SprintFunc(),
+	color.New(color.FgYellow).SprintFunc(),
+	color.New(color.FgHiCyan).SprintFunc(),
+	color.New(color.FgHiMagenta).SprintFunc(),
+	color.New(color.FgHiBlue).SprintFunc(),
+}
+
+// execTargets lists the repositories to run in, from the manifest when one is given
+func execTargets(dirs []string) []execTarget {
+	var targets []execTarget
+	if cfg.Manifest != "" {
+		m, err := loadManifest(cfg.Manifest)
+		if err != nil {
+			log.Fatal(red(err.Error()))
+		}
+		for _, r := range m.Repos {
+			if _, ok := inspectRepo(m.root, m.dir(r)); ok {
+				targets = append(targets, execTarget{Name: r.Path, Path: m.dir(r), Groups: r.Groups})
+			} else {
+				logVerbose(fmt.Sprintf("Skipping %s: not cloned yet", r.Path))
+			}
+		}
+		return targets
+	}
+
+	for _, r := range findRepos(dirs) {
+		targets = append(targets, execTarget{Name: r.Rel, Path: r.Path})
+	}
+	return targets
+}
+
+// filterTargets keeps the repositories that match every filter
+func filterTargets(targets []execTarget, filters []string) ([]execTarget, error) {
+	for _, filter := range filters {
+		key, value, _ := strings.Cut(filter, "=")
+		switch key {
+		case "branch", "dirty", "clean":
+		case "group":
+			if cfg.Manifest == "" {
+				return nil, fmt.Errorf("filter %q needs a workspace manifest (--manifest)", filter)
+			}
+		default:
+			return nil, fmt.Errorf("unknown filter %q (expected branch=<name>, dirty, clean or group=<name>)", filter)
+		}
+		if value == "" && (key == "branch" || key == "group") {
+			return nil, fmt.Errorf("filter %q needs a value", filter)
+		}
+	}
+	if len(cfg.Groups) > 0 && cfg.Manifest == "" {
+		return nil, fmt.Errorf("--group needs a workspace manifest (--manifest)")
+	}
+
+	var kept []execTarget
+	for _, t := range targets {
+		if matchesFilters(t, filters) && (len(cfg.Groups) == 0 || inAnyGroup(t.Groups, cfg.Groups)) {
+			kept = append(kept, t)
+		}
+	}
+	return kept, nil
+}
+
+func matchesFilters(t execTarget, filters []string) bool {
+	for _, filter := range filters {
+		key, value, _ := strings.Cut(filter, "=")
+		switch key {
+		case "branch":
+			branch, _ := git(t.Path, "branch", "--show-current")
+			if ok, _ := path.Match(value, branch); !ok {
+				return false
+			}
+		case "dirty", "clean":
+			status, _ := git(t.Path, "status", "--porcelain")
+			if (status != "") != (key == "dirty") {
+				return false
+			}
+		case "group":
+			if !inAnyGroup(t.Groups, []string{value}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// runIn runs the command in a repository, streaming its output through w
+func runIn(t execTarget, command []string, w *prefixWriter) execResult {
+	var c *exec.Cmd
+	if len(command) == 1 {
+		c = exec.Command("sh", "-c", command[0])
+	} else {
+		c = exec.Command(command[0], command[1:]...)
+	}
+	c.Dir = t.Path
+	if !execQuiet {
+		c.Stdout = w
+		c.Stderr = w
+	}
+
+	start := time.Now()
+	err := c.Run()
+	w.flush()
+
+	res := execResult{Target: t, Duration: time.Since(start)}
+	if err != nil {
+		res.Err = err
+		res.ExitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			res.ExitCode = exitErr.ExitCode()
+		}
+	}
+	return res
+}
+
+// printExecSummary prints pass/fail per repository and reports whether any failed
+func printExecSummary(results []execResult) bool {
+	passed, failed := 0, 0
+	fmt.Println()
+	for _, r := range results {
+		if r.Err == nil {
+			passed++
+			continue
+		}
+		failed++
+		detail := fmt.Sprintf("exit code %d", r.ExitCode)
+		if r.ExitCode == -1 {
+			detail = r.Err.Error()
+		}
+		fmt.Printf("%s %s (%s, %s)\n", red("✗"), r.Target.Name, detail, r.Duration.Round(time.Millisecond))
+	}
+	if failed == 0 {
+		fmt.Println(green(fmt.Sprintf("✓ Passed in all %d repositories", passed)))
+		return false
+	}
+	fmt.Println(red(fmt.Sprintf("%d passed, %d failed", passed, failed)))
+	return true
+}
+
+// prefixWriter writes complete lines with a repository prefix, holding a
+// shared lock per line so output from concurrent repositories never interleaves
+type prefixWriter struct {
+	prefix string
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial line until the rest of it arrives
+			w.buf.Reset()
+			w.buf.Write(line)
+			return len(p), nil
+		}
+		w.print(line)
+	}
+}
+
+func (w *prefixWriter) flush() {
+	if w.buf.Len() > 0 {
+		w.print(append(w.buf.Bytes(), '\n'))
+		w.buf.Reset()
+	}
+}
+
+func (w *prefixWriter) print(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Print(w.prefix, string(line))
+}
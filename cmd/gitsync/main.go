@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Depth       int
+	Ignore      []string
+	Jobs        int
+	Push        bool
+	FetchOnly   bool
+	Submodules  bool
+	Manifest    string
+	Groups      []string
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "gitsync [directory...]",
+		Short: "Fetch and pull every Git repository under a directory in parallel",
+		Args:  cobra.ArbitraryArgs,
+		Run:   gitSync,
+	}
+
+	// Command-line flags
+	rootCmd.PersistentFlags().IntVarP(&cfg.Depth, "depth", "d", 3, "How many directory levels to search for repositories (0 for unlimited)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.Ignore, "ignore", nil, "Directory name patterns to skip, in addition to the defaults")
+	rootCmd.PersistentFlags().IntVarP(&cfg.Jobs, "jobs", "j", 8, "Number of repositories to process at once")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Submodules, "submodules", false, "Include submodules and update them after pulling")
+	rootCmd.PersistentFlags().StringVarP(&cfg.Manifest, "manifest", "m", "", "Workspace manifest (JSON) listing the repositories to clone and sync")
+	rootCmd.PersistentFlags().StringSliceVarP(&cfg.Groups, "group", "g", nil, "Only handle manifest repositories in these groups")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.Flags().BoolVarP(&cfg.Push, "push", "p", false, "Push local commits after pulling")
+	rootCmd.Flags().BoolVar(&cfg.FetchOnly, "fetch-only", false, "Only fetch; report how far behind each repository is")
+	rootCmd.AddCommand(newExecCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func gitSync(cmd *cobra.Command, args []string) {
+	if cfg.Manifest != "" {
+		syncWorkspace()
+		return
+	}
+	if len(cfg.Groups) > 0 {
+		log.Fatal(red("--group needs a workspace manifest (--manifest)"))
+	}
+
+	repos := findRepos(args)
+	if len(repos) == 0 {
+		fmt.Println(yellow("No Git repositories found."))
+		return
+	}
+
+	fetcher := newFetcher()
+	results := runAll(repoNames(repos), func(i int) result {
+		return syncRepo(repos[i], fetcher)
+	})
+
+	printSummary(results)
+	if anyFailed(results) {
+		os.Exit(1)
+	}
+}
+
+// findRepos discovers repositories under each directory argument, or the current directory
+func findRepos(dirs []string) []repo {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	ignore := append(append([]string{}, defaultIgnores...), cfg.Ignore...)
+
+	var repos []repo
+	for _, dir := range dirs {
+		found, err := discoverRepos(dir, cfg.Depth, ignore, cfg.Submodules)
+		if err != nil {
+			log.Fatalf(red("Failed to search %s: %v"), dir, err)
+		}
+		repos = append(repos, found...)
+	}
+	if cfg.Submodules && !cfg.FetchOnly {
+		repos = skipUpdatedSubmodules(repos)
+	}
+	logVerbose(fmt.Sprintf("Found %d repositories", len(repos)))
+	return repos
+}
+
+// repoNames returns the display name of each repository
+func repoNames(repos []repo) []string {
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		names[i] = r.Rel
+	}
+	return names
+}
+
+// runAll processes each named item with a bounded pool of workers, showing
+// progress in a live table, and returns the results in the same order
+func runAll(names []string, work func(int) result) []result {
+	table := newLiveTable(names)
+	table.start()
+
+	jobs := cfg.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	indexes := make(chan int)
+	results := make([]result, len(names))
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				table.update(i, result{Status: statusRunning})
+				results[i] = work(i)
+				table.update(i, results[i])
+			}
+		}()
+	}
+	for i := range names {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	table.stop()
+	return results
+}
+
+// fetcher fetches each shared git directory once, so worktrees of the same
+// repository do not fetch concurrently
+type fetcher struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+	done  map[string]error
+}
+
+func newFetcher() *fetcher {
+	return &fetcher{locks: map[string]*sync.Mutex{}, done: map[string]error{}}
+}
+
+func (f *fetcher) fetch(r repo) error {
+	f.mu.Lock()
+	lock, ok := f.locks[r.CommonDir]
+	if !ok {
+		lock = &sync.Mutex{}
+		f.locks[r.CommonDir] = lock
+	}
+	f.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+	f.mu.Lock()
+	err, fetched := f.done[r.CommonDir]
+	f.mu.Unlock()
+	if fetched {
+		return err
+	}
+
+	_, err = git(r.Path, "fetch", "--all", "--prune", "--quiet")
+	f.mu.Lock()
+	f.done[r.CommonDir] = err
+	f.mu.Unlock()
+	return err
+}
+
+// syncRepo fetches a repository and fast-forwards or rebases its current branch
+func syncRepo(r repo, f *fetcher) result {
+	branch, _ := git(r.Path, "branch", "--show-current")
+	res := result{Branch: branch}
+	if branch == "" {
+		res.Status, res.Message = statusSkipped, "detached HEAD"
+		return res
+	}
+
+	if err := f.fetch(r); err != nil {
+		res.Status, res.Message = statusError, "fetch failed: "+firstLine(err.Error())
+		return res
+	}
+
+	upstream, err := git(r.Path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
+	if err != nil {
+		res.Status, res.Message = statusSkipped, "no upstream"
+		return res
+	}
+	ahead, behind := aheadBehind(r.Path, upstream)
+
+	if cfg.FetchOnly {
+		res.Status, res.Message = statusUpToDate, describeDivergence(upstream, ahead, behind)
+		if behind > 0 {
+			res.Status = statusBehind
+		}
+		return res
+	}
+
+	if behind > 0 {
+		if dirty, _ := git(r.Path, "status", "--porcelain", "--untracked-files=no"); dirty != "" {
+			res.Status, res.Message = statusDirty, fmt.Sprintf("uncommitted changes, %d behind %s", behind, upstream)
+			return res
+		}
+		if _, err := git(r.Path, "rebase", "--quiet", upstream); err != nil {
+			if _, abortErr := git(r.Path, "rebase", "--abort"); abortErr == nil {
+				res.Status, res.Message = statusConflict, fmt.Sprintf("rebase onto %s conflicts, left unchanged", upstream)
+				return res
+			}
+			res.Status, res.Message = statusError, "rebase failed: "+firstLine(err.Error())
+			return res
+		}
+		res.Status, res.Message = statusUpdated, fmt.Sprintf("pulled %d commit(s) from %s", behind, upstream)
+	} else {
+		res.Status, res.Message = statusUpToDate, describeDivergence(upstream, ahead, 0)
+	}
+
+	if cfg.Submodules && fileExists(r.Path, ".gitmodules") {
+		if _, err := git(r.Path, "submodule", "update", "--init", "--recursive"); err != nil {
+			res.Status, res.Message = statusError, "submodule update failed: "+firstLine(err.Error())
+			return res
+		}
+	}
+
+	if cfg.Push && ahead > 0 {
+		if _, err := git(r.Path, "push", "--quiet"); err != nil {
+			res.Status, res.Message = statusError, "push failed: "+firstLine(err.Error())
+			return res
+		}
+		res.Status = statusPushed
+		res.Message = strings.TrimPrefix(res.Message+fmt.Sprintf(", pushed %d commit(s)", ahead), ", ")
+	}
+	return res
+}
+
+// aheadBehind counts commits on each side of the current branch and its upstream
+func aheadBehind(dir, upstream string) (int, int) {
+	output, err := git(dir, "rev-list", "--left-right", "--count", "HEAD..."+upstream)
+	if err != nil {
+		return 0, 0
+	}
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0
+	}
+	ahead, _ := strconv.Atoi(fields[0])
+	behind, _ := strconv.Atoi(fields[1])
+	return ahead, behind
+}
+
+func describeDivergence(upstream string, ahead, behind int) string {
+	switch {
+	case ahead > 0 && behind > 0:
+		return fmt.Sprintf("%d ahead, %d behind %s", ahead, behind, upstream)
+	case behind > 0:
+		return fmt.Sprintf("%d behind %s", behind, upstream)
+	case ahead > 0:
+		return fmt.Sprintf("%d ahead of %s", ahead, upstream)
+	}
+	return "in sync with " + upstream
+}
+
+func anyFailed(results []result) bool {
+	for _, r := range results {
+		if r.failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// git runs a git command in dir and returns its trimmed stdout
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func fileExists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// manifest describes a workspace of repositories, for example:
+//
+//	{
+//	  "repos": [
+//	    {"path": "services/api", "url": "git@github.com:acme/api.git", "branch": "main", "groups": ["backend"]},
+//	    {"path": "web", "url": "../remotes/web.git", "groups": ["frontend"]}
+//	  ]
+//	}
+//
+// Paths, and URLs that are relative filesystem paths, are resolved against
+// the directory containing the manifest.
+type manifest struct {
+	Repos []manifestRepo `json:"repos"`
+
+	root string
+}
+
+// manifestRepo is one repository entry in a workspace manifest
+type manifestRepo struct {
+	Path   string   `json:"path"`
+	URL    string   `json:"url"`
+	Branch string   `json:"branch,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// loadManifest reads and validates a workspace manifest
+func loadManifest(path string) (*manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	m.root = filepath.Dir(absPath)
+
+	seen := map[string]bool{}
+	for i, r := range m.Repos {
+		if r.Path == "" || r.URL == "" {
+			return nil, fmt.Errorf("manifest entry %d needs both a path and a url", i+1)
+		}
+		clean := filepath.Clean(r.Path)
+		if seen[clean] {
+			return nil, fmt.Errorf("manifest lists %s more than once", r.Path)
+		}
+		seen[clean] = true
+		m.Repos[i].Path = clean
+	}
+	return &m, nil
+}
+
+// selected returns the entries in any of the groups, or all entries when no group is given
+func (m *manifest) selected(groups []string) []manifestRepo {
+	if len(groups) == 0 {
+		return m.Repos
+	}
+	var repos []manifestRepo
+	for _, r := range m.Repos {
+		if inAnyGroup(r.Groups, groups) {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+func inAnyGroup(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dir returns the absolute checkout path of an entry
+func (m *manifest) dir(r manifestRepo) string {
+	return filepath.Join(m.root, r.Path)
+}
+
+// cloneURL resolves relative local paths so bare repositories next to the
+// manifest work as remotes regardless of the current directory
+func (m *manifest) cloneURL(r manifestRepo) string {
+	url := r.URL
+	if strings.Contains(url, "://") || filepath.IsAbs(url) || isSCPLike(url) {
+		return url
+	}
+	return filepath.Join(m.root, url)
+}
+
+// isSCPLike matches user@host:path style URLs
+func isSCPLike(url string) bool {
+	colon := strings.Index(url, ":")
+	slash := strings.Index(url, "/")
+	return colon > 0 && (slash == -1 || colon < slash)
+}
+
+// syncWorkspace clones missing manifest repositories, syncs existing ones and
+// reports repositories on disk that the manifest does not know about
+func syncWorkspace() {
+	m, err := loadManifest(cfg.Manifest)
+	if err != nil {
+		log.Fatal(red(err.Error()))
+	}
+
+	entries := m.selected(cfg.Groups)
+	if len(entries) == 0 {
+		log.Fatalf(red("No repositories in %s match group(s) %s"), cfg.Manifest, strings.Join(cfg.Groups, ", "))
+	}
+
+	names := make([]string, len(entries))
+	for i, r := range entries {
+		names[i] = r.Path
+	}
+
+	var extras []repo
+	if len(cfg.Groups) == 0 {
+		// Only a full sync can tell which repositories are extra
+		extras = extraRepos(m)
+		for _, r := range extras {
+			names = append(names, r.Rel)
+		}
+	}
+
+	fetcher := newFetcher()
+	results := runAll(names, func(i int) result {
+		if i >= len(entries) {
+			branch, _ := git(extras[i-len(entries)].Path, "branch", "--show-current")
+			return result{Status: statusExtra, Branch: branch, Message: "not in manifest"}
+		}
+		return syncEntry(m, entries[i], fetcher)
+	})
+
+	printSummary(results)
+	if anyFailed(results) {
+		os.Exit(1)
+	}
+}
+
+// syncEntry clones a manifest repository if it is missing, otherwise syncs it
+func syncEntry(m *manifest, entry manifestRepo, f *fetcher) result {
+	dir := m.dir(entry)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return cloneEntry(m, entry)
+	}
+
+	r, ok := inspectRepo(m.root, dir)
+	if !ok {
+		return result{Status: statusError, Message: "exists but is not a git repository"}
+	}
+
+	res := syncRepo(r, f)
+	if origin, err := git(dir, "remote", "get-url", "origin"); err == nil && !sameRemote(origin, m.cloneURL(entry)) {
+		res.Message = strings.TrimPrefix(res.Message+"; origin is "+origin+", manifest says "+entry.URL, "; ")
+	}
+	return res
+}
+
+// cloneEntry clones a missing repository at its manifest path and branch
+func cloneEntry(m *manifest, entry manifestRepo) result {
+	dir := m.dir(entry)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return result{Status: statusError, Message: err.Error()}
+	}
+
+	args := []string{"clone", "--quiet"}
+	if entry.Branch != "" {
+		args = append(args, "--branch", entry.Branch)
+	}
+	if cfg.Submodules {
+		args = append(args, "--recurse-submodules")
+	}
+	args = append(args, m.cloneURL(entry), dir)
+	if _, err := git(m.root, args...); err != nil {
+		return result{Status: statusError, Message: "clone failed: " + firstLine(err.Error())}
+	}
+
+	branch, _ := git(dir, "branch", "--show-current")
+	return result{Status: statusCloned, Branch: branch, Message: "cloned from " + entry.URL}
+}
+
+// extraRepos lists repositories under the workspace root that are not in the manifest
+func extraRepos(m *manifest) []repo {
+	ignore := append(append([]string{}, defaultIgnores...), cfg.Ignore...)
+	found, err := discoverRepos(m.root, cfg.Depth, ignore, false)
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not search %s for extra repositories: %v", m.root, err))
+		return nil
+	}
+
+	known := map[string]bool{}
+	for _, r := range m.Repos {
+		known[m.dir(r)] = true
+	}
+	var extras []repo
+	for _, r := range found {
+		if !known[r.Path] && r.Path != m.root && !r.Worktree {
+			extras = append(extras, r)
+		}
+	}
+	return extras
+}
+
+// sameRemote compares remote URLs, ignoring trailing slashes and .git suffixes
+func sameRemote(a, b string) bool {
+	normalize := func(url string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	}
+	return normalize(a) == normalize(b)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repository sync statuses
+const (
+	statusPending  = "pending"
+	statusRunning  = "running"
+	statusUpdated  = "updated"
+	statusUpToDate = "up-to-date"
+	statusBehind   = "behind"
+	statusDirty    = "dirty-skipped"
+	statusSkipped  = "skipped"
+	statusConflict = "conflict"
+	statusError    = "error"
+	statusPushed   = "pushed"
+	statusCloned   = "cloned"
+	statusExtra    = "extra"
+)
+
+// result is the outcome of processing one repository
+type result struct {
+	Status  string
+	Branch  string
+	Message string
+}
+
+// failed reports whether the result should make the command exit non-zero
+func (r result) failed() bool {
+	return r.Status == statusConflict || r.Status == statusError
+}
+
+// liveTable prints one row per repository, redrawing in place on a terminal
+// and falling back to one line per finished repository otherwise
+type liveTable struct {
+	mu      sync.Mutex
+	names   []string
+	results []result
+	live    bool
+	drawn   int
+	frame   int
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// maxLiveRows keeps the redraw within a typical terminal height
+const maxLiveRows = 40
+
+func newLiveTable(names []string) *liveTable {
+	t := &liveTable{
+		names:   names,
+		results: make([]result, len(names)),
+		live:    isTerminal() && len(names) <= maxLiveRows,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for i := range t.results {
+		t.results[i].Status = statusPending
+	}
+	return t
+}
+
+// start begins redrawing the table until stop is called
+func (t *liveTable) start() {
+	if !t.live {
+		close(t.stopped)
+		return
+	}
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.done:
+				t.redraw()
+				return
+			case <-ticker.C:
+				t.redraw()
+			}
+		}
+	}()
+}
+
+// update records a new state for a row
+func (t *liveTable) update(i int, r result) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.results[i] = r
+	if !t.live && r.Status != statusRunning {
+		fmt.Println(formatRow(t.names[i], r, t.nameWidth(), 0))
+	}
+}
+
+func (t *liveTable) stop() {
+	close(t.done)
+	<-t.stopped
+}
+
+func (t *liveTable) redraw() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.drawn > 0 {
+		fmt.Printf("\033[%dA", t.drawn)
+	}
+	width := t.nameWidth()
+	for i, name := range t.names {
+		fmt.Printf("\033[2K%s\n", formatRow(name, t.results[i], width, t.frame))
+	}
+	t.drawn = len(t.names)
+	t.frame++
+}
+
+func (t *liveTable) nameWidth() int {
+	width := 10
+	for _, name := range t.names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	return width
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// formatRow renders a single table row
+func formatRow(name string, r result, width, frame int) string {
+	icon, status := statusStyle(r.Status, frame)
+	branch := r.Branch
+	if branch == "" {
+		branch = "-"
+	}
+	return fmt.Sprintf("%s %-*s  %-13s  %-20s  %s", icon, width, name, status, branch, r.Message)
+}
+
+func statusStyle(status string, frame int) (string, string) {
+	padded := fmt.Sprintf("%-13s", status)
+	switch status {
+	case statusUpdated, statusPushed, statusCloned:
+		return green("✓"), green(padded)
+	case statusUpToDate:
+		return green("✓"), padded
+	case statusBehind, statusDirty, statusSkipped, statusExtra:
+		return yellow("•"), yellow(padded)
+	case statusConflict, statusError:
+		return red("✗"), red(padded)
+	case statusRunning:
+		return yellow(spinnerFrames[frame%len(spinnerFrames)]), padded
+	}
+	return " ", padded
+}
+
+// printSummary prints the count of repositories per status
+func printSummary(results []result) {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	var parts []string
+	for _, status := range []string{statusCloned, statusUpdated, statusPushed, statusUpToDate, statusBehind, statusDirty, statusSkipped, statusExtra, statusConflict, statusError} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	fmt.Printf("\n%s %d repositories: %s\n", yellow("→"), len(results), strings.Join(parts, ", "))
+}
+
+func isTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/amanmehtacode/GitNoob/internal/journal"
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -17,12 +18,17 @@ import (
 
 // Global variables for configuration and styling
 var (
-	pullBeforePush bool
-	verboseMode    bool
-	s              *spinner.Spinner
-	green          = color.New(color.FgGreen).SprintFunc()
-	red            = color.New(color.FgRed).SprintFunc()
-	yellow         = color.New(color.FgYellow).SprintFunc()
+	pullBeforePush   bool
+	pullStrategyFlag string
+	smartPush        bool
+	verboseMode      bool
+	interactive      bool
+	remoteName       string
+	s                *spinner.Spinner
+	stdin            = bufio.NewReader(os.Stdin)
+	green            = color.New(color.FgGreen).SprintFunc()
+	red              = color.New(color.FgRed).SprintFunc()
+	yellow           = color.New(color.FgYellow).SprintFunc()
 )
 
 // main is the entry point of the application
@@ -36,7 +42,11 @@ func main() {
 
 	// Set up command-line flags
 	rootCmd.Flags().BoolVarP(&pullBeforePush, "pull", "p", false, "Pull before pushing")
+	rootCmd.Flags().StringVar(&pullStrategyFlag, "pull-strategy", "", "How to pull with uncommitted work: stash (default) or commit")
+	rootCmd.Flags().BoolVarP(&smartPush, "smart", "s", false, "Auto-resolve trivial conflicts when pulling (SmartPush)")
 	rootCmd.Flags().BoolVarP(&verboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.Flags().BoolVarP(&interactive, "interactive", "i", true, "Show a summary and confirm before pushing")
+	rootCmd.Flags().StringVarP(&remoteName, "remote", "r", "", "Remote to push to (defaults to the configured push remote)")
 
 	// Execute the root command
 	if err := rootCmd.Execute(); err != nil {
@@ -53,37 +63,101 @@ func lazyPush(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	// Pull latest changes if the flag is set
-	if pullBeforePush {
-		fmt.Println(yellow("→ Pulling latest changes..."))
-		if err := runCommand("git", "pull", "--rebase", "origin", currentBranch()); err != nil {
-			logError("Merge conflict or error occurred during pull. Please resolve manually", err)
-			os.Exit(1)
+	// Record the state before anything changes, including a branch created
+	// for a detached HEAD, so rollbackhelper can undo all of it
+	entry, err := journal.Begin("lazypush", "commit and push")
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not record operation for rollbackhelper: %v", err))
+	} else {
+		entry.UndoMode = journal.UndoSoft
+	}
+
+	if err := commitAndPush(entry); err != nil {
+		entry.Fail(err)
+		logError(err.Error(), nil)
+		os.Exit(1)
+	}
+	entry.Finish()
+}
+
+// commitAndPush commits the changes and pushes them. Failures are returned
+// for lazyPush to record in the journal and report.
+func commitAndPush(entry *journal.Entry) error {
+	// Make sure we are on a branch before doing anything else
+	branch, err := ensureBranch()
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		entry.Description = "commit and push " + branch
+	}
+
+	// Pull latest changes if the flag is set, either around a stash or after committing
+	strategy, err := pullStrategy()
+	if err != nil {
+		return fmt.Errorf("Invalid pull strategy: %w", err)
+	}
+	if pullBeforePush && strategy == strategyStash {
+		if err := stashPullPop(branch); err != nil {
+			return fmt.Errorf("Merge conflict or error occurred during pull. Please resolve manually: %w", err)
 		}
 	}
 
 	// Get commit message from user
 	commitMessage := getCommitMessage()
+	if entry != nil {
+		entry.Description = "commit and push: " + commitMessage
+	}
 
 	// Stage and commit changes
 	fmt.Println(yellow("→ Staging and committing changes..."))
 	commitOutput, err := runCommandWithOutput("git", "commit", "-am", commitMessage)
 	if err != nil {
-		logError("Error committing changes", err)
-		return
+		return fmt.Errorf("Error committing changes: %w", err)
 	}
 	printFormattedOutput(commitOutput)
 
-	// Push changes to remote
-	fmt.Println(yellow("→ Pushing changes to remote..."))
-	pushOutput, err := runCommandWithOutput("git", "push", "origin", currentBranch())
+	if pullBeforePush && strategy == strategyCommit {
+		fmt.Println(yellow("→ Rebasing your commit on the latest changes..."))
+		if err := smartPull(branch); err != nil {
+			return fmt.Errorf("Merge conflict or error occurred during pull. Please resolve manually: %w", err)
+		}
+	}
+
+	// Push changes to remote, setting the upstream on first push
+	target, err := resolvePushTarget(branch)
 	if err != nil {
-		logError("Failed to push changes", err)
-		return
+		return fmt.Errorf("Cannot determine where to push: %w", err)
+	}
+	printPushSummary(target, buildPushSummary(target))
+	if interactive && !confirm("Push these changes?") {
+		fmt.Println(yellow("Push cancelled. Your commit is kept locally."))
+		return nil
+	}
+
+	fmt.Println(yellow(fmt.Sprintf("→ Pushing changes to %s...", target)))
+	pushOutput, err := runCommandWithOutput("git", target.args()...)
+	if err != nil && smartPush {
+		// SmartPush: the remote moved on, so rebase onto it and try once more
+		fmt.Println(yellow("→ Push rejected. Pulling with smart conflict resolution..."))
+		if err := smartPull(branch); err != nil {
+			return fmt.Errorf("Could not integrate remote changes: %w", err)
+		}
+		pushOutput, err = runCommandWithOutput("git", target.args()...)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to push changes: %w", err)
 	}
 	printFormattedOutput(pushOutput)
+	reportUpstream(target)
+	hash, err := getLastCommitHash()
+	if err != nil {
+		return fmt.Errorf("Error getting last commit hash: %w", err)
+	}
+	fmt.Printf("%s %s\n", yellow("→"), commitLink(target.Remote, hash))
 
 	fmt.Println(green("✓ Changes have been committed and pushed successfully! 🚀"))
+	return nil
 }
 
 // printFormattedOutput formats and prints the output of git commands
@@ -103,10 +177,7 @@ func printFormattedOutput(output string) {
 
 // getCommitMessage prompts the user for a commit message or uses a default one
 func getCommitMessage() string {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print(yellow("Enter commit message (leave empty for default): "))
-	commitMessage, _ := reader.ReadString('\n')
-	commitMessage = strings.TrimSpace(commitMessage)
+	commitMessage := promptForInput("Enter commit message (leave empty for default): ")
 
 	if commitMessage == "" {
 		commitMessage = fmt.Sprintf("Auto commit on %s", time.Now().Format(time.RFC1123))
@@ -115,6 +186,13 @@ func getCommitMessage() string {
 	return commitMessage
 }
 
+// promptForInput prints a prompt and reads a trimmed line from stdin
+func promptForInput(prompt string) string {
+	fmt.Print(yellow(prompt))
+	input, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(input)
+}
+
 // runCommandWithOutput executes a command and returns its output as a string
 func runCommandWithOutput(name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
@@ -127,13 +205,21 @@ func pushChanges() error {
 	startSpinner("Pushing changes")
 	defer stopSpinner()
 
-	err := runCommand("git", "push", "origin", currentBranch())
+	branch, err := currentBranch()
+	if err != nil {
+		return err
+	}
+	target, err := resolvePushTarget(branch)
+	if err != nil {
+		return err
+	}
+	err = runCommand("git", target.args()...)
 	if err != nil {
 		fmt.Println("Initial push failed. Trying to pull the latest changes and push again...")
-		if err := runCommand("git", "pull", "--rebase", "origin", currentBranch()); err != nil {
+		if err := smartPull(branch); err != nil {
 			return fmt.Errorf("pull (rebase) failed: %w", err)
 		}
-		if err := runCommand("git", "push", "origin", currentBranch()); err != nil {
+		if err := runCommand("git", target.args()...); err != nil {
 			return fmt.Errorf("push failed again: %w", err)
 		}
 		fmt.Println("Changes have been committed and pushed successfully after resolving conflicts.")
@@ -162,14 +248,13 @@ func hasUnstagedChanges() bool {
 	return len(output) > 0
 }
 
-// currentBranch gets the name of the current git branch
-func currentBranch() string {
+// currentBranch gets the name of the current git branch, or "" on a detached HEAD
+func currentBranch() (string, error) {
 	output, err := exec.Command("git", "branch", "--show-current").Output()
 	if err != nil {
-		logError("Error getting current branch", err)
-		os.Exit(1)
+		return "", fmt.Errorf("Error getting current branch: %w", err)
 	}
-	return strings.TrimSpace(string(output))
+	return strings.TrimSpace(string(output)), nil
 }
 
 // logVerbose logs a message if verbose mode is enabled
@@ -201,11 +286,10 @@ func stopSpinner() {
 }
 
 // getLastCommitHash retrieves the hash of the last commit
-func getLastCommitHash() string {
+func getLastCommitHash() (string, error) {
 	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
 	if err != nil {
-		logError("Error getting last commit hash", err)
-		os.Exit(1)
+		return "", err
 	}
-	return strings.TrimSpace(string(output))
+	return strings.TrimSpace(string(output)), nil
 }
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// defaultUnionPatterns are merged by keeping both sides unless configured otherwise
+var defaultUnionPatterns = []string{"CHANGELOG*", "CHANGES*", "HISTORY*"}
+
+// autoResolution records how a conflicted file was resolved automatically
+type autoResolution struct {
+	Path   string
+	Reason string
+}
+
+// smartPull pulls with --rebase and, in smart mode, resolves trivial conflicts
+// commit by commit until the rebase finishes or a real conflict is found
+func smartPull(branch string) error {
+	err := pullLatest(branch)
+	if err == nil || !smartPush || !rebaseInProgress() {
+		return err
+	}
+
+	var resolved []autoResolution
+	for rebaseInProgress() {
+		step := rebaseStepState()
+		conflicts := conflictedFiles()
+		if len(conflicts) == 0 {
+			// Nothing left to resolve in this step; an empty commit can simply be skipped
+			if err := continueRebase(); err != nil {
+				return err
+			}
+			if rebaseInProgress() && rebaseStepState() == step {
+				fmt.Println(red("✗ The rebase is not making progress; it needs your attention."))
+				fmt.Println("Check 'git status', then run 'git rebase --continue' (or 'git rebase --abort').")
+				return fmt.Errorf("the rebase stopped on the same commit")
+			}
+			continue
+		}
+
+		var remaining []string
+		for _, file := range conflicts {
+			reason, ok := autoResolve(file)
+			if !ok {
+				remaining = append(remaining, file)
+				continue
+			}
+			resolved = append(resolved, autoResolution{Path: file, Reason: reason})
+			logVerbose(fmt.Sprintf("Auto-resolved %s (%s)", file, reason))
+		}
+
+		if len(remaining) > 0 {
+			printAutoResolutions(resolved)
+			fmt.Println(red("✗ Real conflicts need your attention:"))
+			for _, file := range remaining {
+				fmt.Printf("  %s\n", file)
+			}
+			fmt.Println("Resolve them, then run 'git add <file>' and 'git rebase --continue' (or 'git rebase --abort').")
+			return fmt.Errorf("%d conflicted file(s) could not be resolved automatically", len(remaining))
+		}
+
+		if err := continueRebase(); err != nil {
+			return err
+		}
+		if rebaseInProgress() && rebaseStepState() == step {
+			printAutoResolutions(resolved)
+			fmt.Println(red("✗ The rebase is not making progress; it needs your attention."))
+			fmt.Println("Check 'git status', resolve what is left, then run 'git rebase --continue' (or 'git rebase --abort').")
+			return fmt.Errorf("the rebase stopped on the same commit with the same conflicts")
+		}
+	}
+
+	printAutoResolutions(resolved)
+	return nil
+}
+
+// autoResolve tries each safe strategy on a conflicted file and stages the result
+func autoResolve(file string) (string, bool) {
+	stages := conflictStages(file)
+	if !stages.hasBoth() {
+		// modify/delete and rename conflicts are never trivial
+		return "", false
+	}
+
+	if bytes.Equal(stages.Ours, stages.Theirs) {
+		if err := writeAndStage(file, stages.Theirs); err != nil {
+			return "", false
+		}
+		return "identical changes on both sides", true
+	}
+
+	if command, ok := regenerateCommand(file); ok {
+		if err := regenerate(file, command); err != nil {
+			logVerbose(fmt.Sprintf("Regenerating %s failed: %v", file, err))
+			return "", false
+		}
+		return "regenerated with '" + command + "'", true
+	}
+
+	if matchesAny(file, unionPatterns()) {
+		merged, err := unionMerge(stages)
+		if err != nil {
+			logVerbose(fmt.Sprintf("Union merge of %s failed: %v", file, err))
+			return "", false
+		}
+		if err := writeAndStage(file, merged); err != nil {
+			return "", false
+		}
+		return "union merge", true
+	}
+
+	if bytes.IndexByte(stages.Ours, 0) != -1 || bytes.IndexByte(stages.Theirs, 0) != -1 {
+		return "", false
+	}
+
+	content, err := os.ReadFile(repoPath(file))
+	if err != nil {
+		return "", false
+	}
+	merged, reason, ok := resolveTrivialHunks(content)
+	if !ok {
+		return "", false
+	}
+	if err := writeAndStage(file, merged); err != nil {
+		return "", false
+	}
+	return reason, true
+}
+
+// stageContents holds the base, upstream ("ours" during a rebase) and local ("theirs") versions
+type stageContents struct {
+	Base, Ours, Theirs          []byte
+	HasBase, HasOurs, HasTheirs bool
+}
+
+func (s stageContents) hasBoth() bool {
+	return s.HasOurs && s.HasTheirs
+}
+
+// conflictStages reads the index stages of a conflicted file
+func conflictStages(file string) stageContents {
+	var stages stageContents
+	stages.Base, stages.HasBase = showStage(1, file)
+	stages.Ours, stages.HasOurs = showStage(2, file)
+	stages.Theirs, stages.HasTheirs = showStage(3, file)
+	return stages
+}
+
+func showStage(stage int, file string) ([]byte, bool) {
+	output, err := exec.Command("git", "show", fmt.Sprintf(":%d:%s", stage, file)).Output()
+	if err != nil {
+		return nil, false
+	}
+	return output, true
+}
+
+// resolveTrivialHunks walks the conflict markers in a file and resolves every
+// block whose sides are identical or differ only in whitespace, keeping the
+// local side. It fails if any block is a real conflict.
+func resolveTrivialHunks(content []byte) ([]byte, string, bool) {
+	var out bytes.Buffer
+	var ours, theirs []string
+	state := 0 // 0 outside, 1 upstream side, 2 base section (diff3), 3 local side
+	whitespaceOnly := false
+
+	lines := strings.SplitAfter(string(content), "\n")
+	for _, line := range lines {
+		switch {
+		case state == 0 && strings.HasPrefix(line, "<<<<<<< "):
+			state, ours, theirs = 1, nil, nil
+		case state != 0 && strings.HasPrefix(line, "||||||| "):
+			state = 2
+		case state != 0 && strings.TrimRight(line, "\r\n") == "=======":
+			state = 3
+		case state == 3 && strings.HasPrefix(line, ">>>>>>> "):
+			state = 0
+			upstream, local := strings.Join(ours, ""), strings.Join(theirs, "")
+			if upstream != local {
+				if normalizeWhitespace(upstream) != normalizeWhitespace(local) {
+					return nil, "", false
+				}
+				whitespaceOnly = true
+			}
+			out.WriteString(local)
+		case state == 1:
+			ours = append(ours, line)
+		case state == 3:
+			theirs = append(theirs, line)
+		case state == 2:
+		default:
+			out.WriteString(line)
+		}
+	}
+	if state != 0 {
+		return nil, "", false
+	}
+
+	if whitespaceOnly {
+		return out.Bytes(), "whitespace-only differences", true
+	}
+	return out.Bytes(), "identical changes on both sides", true
+}
+
+// normalizeWhitespace trims each line and collapses the runs of whitespace
+// within it, so indentation and spacing changes compare equal but words that
+// were joined or split do not
+func normalizeWhitespace(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\r\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unionMerge keeps the lines from both sides using git merge-file --union
+func unionMerge(stages stageContents) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "lazypush-union")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{"ours": stages.Ours, "base": stages.Base, "theirs": stages.Theirs}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	// Put the local entries first so new changelog lines stay on top
+	cmd := exec.Command("git", "merge-file", "-p", "--union",
+		filepath.Join(dir, "theirs"), filepath.Join(dir, "base"), filepath.Join(dir, "ours"))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// regenerate takes the upstream version of a generated file and reruns its generator
+// from the top of the work tree
+func regenerate(file, command string) error {
+	if err := runCommand("git", "checkout", "--ours", "--", repoPath(file)); err != nil {
+		return err
+	}
+	startSpinner(fmt.Sprintf("Regenerating %s", file))
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = repoRoot()
+	output, err := cmd.CombinedOutput()
+	stopSpinner()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return runCommand("git", "add", "--", repoPath(file))
+}
+
+// regenerateCommand looks up the command configured for a generated file.
+// Entries are set with: git config --add lazypush.regenerate "package-lock.json:npm install --package-lock-only"
+func regenerateCommand(file string) (string, bool) {
+	for _, entry := range gitConfigAll("lazypush.regenerate") {
+		pattern, command, ok := strings.Cut(entry, ":")
+		if ok && matchesAny(file, []string{strings.TrimSpace(pattern)}) {
+			return strings.TrimSpace(command), true
+		}
+	}
+	return "", false
+}
+
+// unionPatterns returns the configured union-merge patterns, or the defaults
+func unionPatterns() []string {
+	if patterns := gitConfigAll("lazypush.union"); len(patterns) > 0 {
+		return patterns
+	}
+	return defaultUnionPatterns
+}
+
+// matchesAny reports whether the path or its base name matches one of the glob patterns
+func matchesAny(file string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, file); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAndStage(file string, content []byte) error {
+	if err := os.WriteFile(repoPath(file), content, 0644); err != nil {
+		return err
+	}
+	return runCommand("git", "add", "--", repoPath(file))
+}
+
+// repoRoot is the top of the work tree
+func repoRoot() string {
+	return strings.TrimSpace(gitOutput("rev-parse", "--show-toplevel"))
+}
+
+// repoPath turns a path as git reports it, relative to the top of the work
+// tree, into one that works from any directory inside it
+func repoPath(file string) string {
+	return filepath.Join(repoRoot(), filepath.FromSlash(file))
+}
+
+// rebaseStepState identifies where a rebase stands: the commit being applied
+// and the files still in conflict. It stays the same when a pass makes no
+// progress.
+func rebaseStepState() string {
+	return strings.TrimSpace(gitOutput("rev-parse", "--verify", "-q", "REBASE_HEAD")) + " " + strings.Join(conflictedFiles(), " ")
+}
+
+// conflictedFiles lists the unmerged paths in the index, relative to the top
+// of the work tree
+func conflictedFiles() []string {
+	return nonEmptyLines(gitOutput("diff", "--name-only", "--diff-filter=U"))
+}
+
+// rebaseInProgress reports whether a rebase is stopped in this repository
+func rebaseInProgress() bool {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if info, err := os.Stat(strings.TrimSpace(gitOutput("rev-parse", "--git-path", dir))); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// continueRebase continues a rebase without opening an editor, skipping
+// commits that became empty after resolution. Stopping again on the next
+// conflicting commit is not an error; the caller resolves it in turn.
+func continueRebase() error {
+	output, err := rebaseStep("--continue")
+	if err != nil && len(conflictedFiles()) == 0 && isEmptyCommit(output) {
+		output, err = rebaseStep("--skip")
+	}
+	if err == nil || (rebaseInProgress() && len(conflictedFiles()) > 0) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+}
+
+func rebaseStep(action string) (string, error) {
+	cmd := exec.Command("git", "rebase", action)
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func isEmptyCommit(output string) bool {
+	return strings.Contains(output, "--allow-empty") ||
+		strings.Contains(output, "nothing to commit") ||
+		strings.Contains(output, "No changes")
+}
+
+// printAutoResolutions lists the files smart mode resolved on its own
+func printAutoResolutions(resolved []autoResolution) {
+	if len(resolved) == 0 {
+		return
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Auto-resolved %d conflict(s):", len(resolved))))
+	for _, r := range resolved {
+		fmt.Printf("  %s %s\n", r.Path, yellow("("+r.Reason+")"))
+	}
+}
+
+// gitConfigAll reads every value of a multi-valued git config key
+func gitConfigAll(key string) []string {
+	return nonEmptyLines(gitOutput("config", "--get-all", key))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pull strategies for dirty working trees, chosen with --pull-strategy or
+// per repository with: git config lazypush.pullStrategy commit
+const (
+	strategyStash  = "stash"
+	strategyCommit = "commit"
+)
+
+// pullStrategy returns the strategy to use when pulling with uncommitted work
+func pullStrategy() (string, error) {
+	strategy := pullStrategyFlag
+	if strategy == "" {
+		strategy = gitConfig("lazypush.pullStrategy")
+	}
+	switch strings.ToLower(strategy) {
+	case "", strategyStash:
+		return strategyStash, nil
+	case strategyCommit, "commit-first":
+		return strategyCommit, nil
+	}
+	return "", fmt.Errorf("unknown pull strategy %q (expected %q or %q)", strategy, strategyStash, strategyCommit)
+}
+
+// stashPullPop stashes uncommitted work including untracked files, pulls,
+// and restores the work, reporting any conflicts the pop runs into
+func stashPullPop(branch string) error {
+	before := stashRef()
+	fmt.Println(yellow("→ Stashing uncommitted changes..."))
+	if err := runCommand("git", "stash", "push", "--include-untracked", "-m", "lazypush: autostash before pull"); err != nil {
+		return fmt.Errorf("failed to stash changes: %w", err)
+	}
+	if stashRef() == before {
+		// Nothing was stashed, so there is nothing to restore afterwards
+		return smartPull(branch)
+	}
+
+	fmt.Println(yellow("→ Pulling latest changes..."))
+	if err := smartPull(branch); err != nil {
+		if rebaseInProgress() {
+			fmt.Println(yellow("Your uncommitted changes are saved in stash@{0}. Run 'git stash pop' once the rebase is finished."))
+			return err
+		}
+		if popErr := runCommand("git", "stash", "pop"); popErr != nil {
+			fmt.Println(yellow("Your uncommitted changes are saved in stash@{0}."))
+		}
+		return err
+	}
+
+	fmt.Println(yellow("→ Restoring uncommitted changes..."))
+	output, err := runCommandWithOutput("git", "stash", "pop")
+	if err != nil {
+		conflicts := conflictedFiles()
+		if len(conflicts) == 0 {
+			return fmt.Errorf("failed to restore stashed changes: %s", strings.TrimSpace(output))
+		}
+		fmt.Println(red("✗ Restoring your changes conflicted with the pulled commits:"))
+		for _, file := range conflicts {
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Println("Resolve the conflicts and run 'git stash drop' once you are done; your changes are still in stash@{0}.")
+		return fmt.Errorf("%d file(s) conflicted while restoring the stash", len(conflicts))
+	}
+	logVerbose("Stashed changes restored")
+	return nil
+}
+
+// stashRef returns the commit refs/stash points at, or "" when there are no stashes
+func stashRef() string {
+	return strings.TrimSpace(gitOutput("rev-parse", "-q", "--verify", "refs/stash"))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pushSummary describes what a push is about to send
+type pushSummary struct {
+	Base    string
+	Commits []string
+	Files   []fileStat
+	Ahead   int
+	Behind  int
+	NewRef  bool
+}
+
+// fileStat holds the line counts for one changed file
+type fileStat struct {
+	Path       string
+	Insertions string
+	Deletions  string
+}
+
+// remoteRef returns the remote-tracking ref for the target, if it exists locally
+func (t pushTarget) remoteRef() (string, bool) {
+	ref := fmt.Sprintf("refs/remotes/%s/%s", t.Remote, t.RemoteBranch)
+	if err := runCommand("git", "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return "", false
+	}
+	return ref, true
+}
+
+// buildPushSummary collects outgoing commits, file stats and the ahead/behind count
+func buildPushSummary(target pushTarget) pushSummary {
+	startSpinner(fmt.Sprintf("Fetching %s", target.Remote))
+	if err := runCommand("git", "fetch", "--quiet", target.Remote); err != nil {
+		logVerbose(fmt.Sprintf("Could not fetch %s, summary may be stale: %v", target.Remote, err))
+	}
+	stopSpinner()
+
+	summary := pushSummary{}
+	revRange := []string{"HEAD", "--not", "--remotes=" + target.Remote}
+	if ref, ok := target.remoteRef(); ok {
+		summary.Base = ref
+		revRange = []string{ref + "..HEAD"}
+		counts := strings.Fields(gitOutput("rev-list", "--left-right", "--count", ref+"...HEAD"))
+		if len(counts) == 2 {
+			summary.Behind, _ = strconv.Atoi(counts[0])
+			summary.Ahead, _ = strconv.Atoi(counts[1])
+		}
+	} else {
+		summary.NewRef = true
+	}
+
+	log := gitOutput(append([]string{"log", "--format=%h %s"}, revRange...)...)
+	summary.Commits = nonEmptyLines(log)
+	if summary.NewRef {
+		summary.Ahead = len(summary.Commits)
+	}
+
+	if len(summary.Commits) > 0 {
+		oldest := strings.Fields(summary.Commits[len(summary.Commits)-1])[0]
+		base := oldest + "^"
+		if summary.Base != "" {
+			base = strings.TrimSpace(gitOutput("merge-base", summary.Base, "HEAD"))
+		} else if runCommand("git", "rev-parse", "--verify", "--quiet", base) != nil {
+			base = emptyTree
+		}
+		summary.Files = diffNumstat(base, "HEAD")
+	}
+	return summary
+}
+
+// emptyTree is the hash of git's empty tree, used when diffing a root commit
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// diffNumstat returns per-file insertions and deletions between two revisions
+func diffNumstat(from, to string) []fileStat {
+	var stats []fileStat
+	for _, line := range nonEmptyLines(gitOutput("diff", "--numstat", from, to)) {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		stats = append(stats, fileStat{Insertions: fields[0], Deletions: fields[1], Path: fields[2]})
+	}
+	return stats
+}
+
+// printPushSummary prints the summary produced by buildPushSummary
+func printPushSummary(target pushTarget, summary pushSummary) {
+	fmt.Println(yellow(fmt.Sprintf("→ About to push %s to %s", target.LocalBranch, target)))
+
+	if summary.NewRef {
+		fmt.Printf("  %s does not exist on the remote yet\n", target)
+	} else {
+		fmt.Printf("  %d ahead, %d behind %s\n", summary.Ahead, summary.Behind, target)
+		if summary.Behind > 0 {
+			fmt.Println(red(fmt.Sprintf("  ⚠ %s has %d commit(s) you do not have; the push may be rejected", target, summary.Behind)))
+		}
+	}
+
+	fmt.Printf("\n%s\n", yellow(fmt.Sprintf("Outgoing commits (%d):", len(summary.Commits))))
+	for _, commit := range summary.Commits {
+		hash, subject, _ := strings.Cut(commit, " ")
+		fmt.Printf("  %s %s\n", green(hash), subject)
+	}
+
+	if len(summary.Files) > 0 {
+		insertions, deletions := 0, 0
+		fmt.Printf("\n%s\n", yellow(fmt.Sprintf("Files changed (%d):", len(summary.Files))))
+		for _, file := range summary.Files {
+			if file.Insertions == "-" {
+				fmt.Printf("  %s (binary)\n", file.Path)
+				continue
+			}
+			ins, _ := strconv.Atoi(file.Insertions)
+			del, _ := strconv.Atoi(file.Deletions)
+			insertions += ins
+			deletions += del
+			fmt.Printf("  %s %s %s\n", file.Path, green("+"+file.Insertions), red("-"+file.Deletions))
+		}
+		fmt.Printf("  %s, %s\n", green(fmt.Sprintf("%d insertion(s)", insertions)), red(fmt.Sprintf("%d deletion(s)", deletions)))
+	}
+	fmt.Println()
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(question string) bool {
+	answer := promptForInput(question + " (y/N): ")
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+}
+
+var scpLikeURL = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):(.+)$`)
+
+// commitLink returns a browsable link to the commit on the remote, falling
+// back to the remote URL and hash for remotes that are not web hosts
+func commitLink(remote, hash string) string {
+	url := strings.TrimSpace(gitOutput("remote", "get-url", remote))
+	if url == "" {
+		return hash
+	}
+
+	web := ""
+	switch {
+	case strings.HasPrefix(url, "https://"), strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "ssh://"):
+		if parsed, err := neturl.Parse(url); err == nil {
+			web = "https://" + parsed.Hostname() + parsed.Path
+		}
+	default:
+		if !strings.Contains(url, "://") && !strings.HasPrefix(url, "/") && !strings.HasPrefix(url, ".") {
+			if m := scpLikeURL.FindStringSubmatch(url); m != nil {
+				web = "https://" + m[1] + "/" + m[2]
+			}
+		}
+	}
+
+	if web == "" {
+		return fmt.Sprintf("%s @ %s", url, hash)
+	}
+	web = strings.TrimSuffix(strings.TrimSuffix(web, "/"), ".git")
+	if strings.Contains(web, "bitbucket.org") {
+		return web + "/commits/" + hash
+	}
+	if strings.Contains(web, "gitlab") {
+		return web + "/-/commit/" + hash
+	}
+	return web + "/commit/" + hash
+}
+
+// nonEmptyLines splits output into lines, dropping blank ones
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// pushTarget describes where the current branch will be pushed
+type pushTarget struct {
+	Remote       string
+	LocalBranch  string
+	RemoteBranch string
+	SetUpstream  bool
+	Matching     bool
+}
+
+// args builds the git push arguments for the target
+func (t pushTarget) args(extra ...string) []string {
+	args := append([]string{"push"}, extra...)
+	if t.SetUpstream {
+		args = append(args, "--set-upstream")
+	}
+	if t.Matching {
+		return append(args, t.Remote, ":")
+	}
+	if t.LocalBranch == t.RemoteBranch {
+		return append(args, t.Remote, t.LocalBranch)
+	}
+	return append(args, t.Remote, fmt.Sprintf("%s:refs/heads/%s", t.LocalBranch, t.RemoteBranch))
+}
+
+// String returns the remote branch the target points at, e.g. origin/main
+func (t pushTarget) String() string {
+	if t.Matching {
+		return t.Remote + " (matching branches)"
+	}
+	return t.Remote + "/" + t.RemoteBranch
+}
+
+// ensureBranch returns the current branch, offering to create one when HEAD is detached
+func ensureBranch() (string, error) {
+	branch, err := currentBranch()
+	if err != nil || branch != "" {
+		return branch, err
+	}
+	if rebaseInProgress() {
+		return "", fmt.Errorf("A rebase is in progress. Finish it with 'git rebase --continue' or abort it with 'git rebase --abort'")
+	}
+
+	head := strings.TrimSpace(gitOutput("rev-parse", "--short", "HEAD"))
+	fmt.Println(yellow(fmt.Sprintf("⚠ HEAD is detached at %s. Pushing requires a branch.", head)))
+	name := promptForInput("Enter a name for a new branch (leave empty to abort): ")
+	if name == "" {
+		return "", fmt.Errorf("Aborted. No branch was created.")
+	}
+
+	if err := runCommand("git", "switch", "-c", name); err != nil {
+		return "", fmt.Errorf("Failed to create branch %s: %w", name, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Created and switched to branch %s", name)))
+	return name, nil
+}
+
+// resolvePushTarget works out the remote and remote branch for a push,
+// following the same configuration git itself consults
+func resolvePushTarget(branch string) (pushTarget, error) {
+	target := pushTarget{
+		Remote:       pushRemote(branch),
+		LocalBranch:  branch,
+		RemoteBranch: branch,
+	}
+
+	upstreamRemote, upstreamBranch, ok := upstreamOf(branch)
+	if !ok {
+		target.SetUpstream = true
+		return target, nil
+	}
+	if remoteName != "" && remoteName != upstreamRemote {
+		// An explicit --remote wins over the upstream configured on another
+		// remote; the branch keeps its name there and its tracking stays as is
+		return target, nil
+	}
+
+	switch mode := gitConfig("push.default"); mode {
+	case "nothing":
+		return target, fmt.Errorf("push.default is set to 'nothing'; push explicitly with 'git push %s %s'", target.Remote, branch)
+	case "upstream", "tracking":
+		target.Remote = upstreamRemote
+		target.RemoteBranch = upstreamBranch
+	case "matching":
+		target.Matching = true
+	case "current":
+	default:
+		// simple: refuse to push to an upstream with a different name, like git does
+		if target.Remote == upstreamRemote && upstreamBranch != branch {
+			return target, fmt.Errorf("upstream %s/%s does not match the branch name %s; set push.default=upstream or rename the branch", upstreamRemote, upstreamBranch, branch)
+		}
+	}
+	return target, nil
+}
+
+// pushRemote returns the remote to push the branch to. An explicit --remote
+// flag wins, then branch.<name>.pushRemote, remote.pushDefault and
+// branch.<name>.remote; otherwise the only remote, or origin.
+func pushRemote(branch string) string {
+	if remoteName != "" {
+		return remoteName
+	}
+	for _, key := range []string{"branch." + branch + ".pushRemote", "remote.pushDefault", "branch." + branch + ".remote"} {
+		if value := gitConfig(key); value != "" && value != "." {
+			return value
+		}
+	}
+	remotes := strings.Fields(gitOutput("remote"))
+	if len(remotes) == 1 {
+		return remotes[0]
+	}
+	return "origin"
+}
+
+// upstreamOf returns the configured upstream remote and branch for a local branch
+func upstreamOf(branch string) (string, string, bool) {
+	remote := gitConfig("branch." + branch + ".remote")
+	merge := gitConfig("branch." + branch + ".merge")
+	if remote == "" || merge == "" {
+		return "", "", false
+	}
+	return remote, strings.TrimPrefix(merge, "refs/heads/"), true
+}
+
+// pullLatest rebases the current branch on its upstream, if it has one
+func pullLatest(branch string) error {
+	remote, upstreamBranch, ok := upstreamOf(branch)
+	if !ok {
+		logVerbose(fmt.Sprintf("Branch %s has no upstream yet, skipping pull", branch))
+		return nil
+	}
+	if remote == "." {
+		return runCommand("git", "rebase", upstreamBranch)
+	}
+	return runCommand("git", "pull", "--rebase", remote, upstreamBranch)
+}
+
+// reportUpstream prints the upstream the branch is now tracking
+func reportUpstream(target pushTarget) {
+	if target.Matching {
+		fmt.Printf("%s pushed matching branches to %s\n", yellow("→"), target.Remote)
+		return
+	}
+	if target.SetUpstream {
+		fmt.Printf("%s %s now tracks %s\n", green("✓"), target.LocalBranch, target.String())
+		return
+	}
+	fmt.Printf("%s pushed %s to %s\n", yellow("→"), target.LocalBranch, target.String())
+}
+
+// gitConfig reads a git config value, returning an empty string when it is unset
+func gitConfig(key string) string {
+	return strings.TrimSpace(gitOutput("config", "--get", key))
+}
+
+// gitOutput runs a git command and returns its stdout, ignoring errors
+func gitOutput(args ...string) string {
+	output, _ := exec.Command("git", args...).Output()
+	return string(output)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Bump        string
+	Version     string
+	Pre         string
+	Prefix      string
+	Path        string
+	VPrefix     string
+	Sign        bool
+	Push        bool
+	Remote      string
+	Yes         bool
+	DryRun      bool
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "release",
+		Short: "Tag the next semantic version from the commits since the last release",
+		Long: `Tag the next semantic version from the commits since the last release.
+
+The commits since the last release tag are read as Conventional Commits: a
+breaking change ("!" or a BREAKING CHANGE footer) bumps the major version, a
+feat the minor version and a fix or perf the patch version. Without any tag
+yet the count starts from 0.0.0. --bump or --version override the result.
+
+With --pre rc the next version becomes a pre-release, numbered after the
+existing ones (1.3.0-rc.1, 1.3.0-rc.2, ...); releasing without --pre then tags
+1.3.0 itself.
+
+In a monorepo, --prefix service-a/ releases only the tags named
+service-a/v1.2.3, counting the commits that touch the service-a directory
+(or --path).
+
+The tag is annotated with the commit subjects, signed with --sign, and pushed
+with --push.`,
+		Args: cobra.NoArgs,
+		Run:  tagRelease,
+	}
+
+	// Command-line flags
+	rootCmd.Flags().StringVarP(&cfg.Bump, "bump", "b", "", "Bump major, minor or patch regardless of the commits")
+	rootCmd.Flags().StringVar(&cfg.Version, "version", "", "Release exactly this version, e.g. 2.0.0")
+	rootCmd.Flags().StringVar(&cfg.Pre, "pre", "", "Pre-release identifier, e.g. alpha, beta or rc")
+	rootCmd.Flags().StringVar(&cfg.Prefix, "prefix", "", "Tag prefix for a package in a monorepo, e.g. service-a/")
+	rootCmd.Flags().StringVar(&cfg.Path, "path", "", "Only count commits under this path (default: the prefix directory, if it exists)")
+	rootCmd.Flags().BoolVarP(&cfg.Sign, "sign", "s", false, "Sign the tag with your GPG key")
+	rootCmd.Flags().BoolVarP(&cfg.Push, "push", "p", false, "Push the tag")
+	rootCmd.Flags().StringVarP(&cfg.Remote, "remote", "r", "origin", "Remote to push to")
+	rootCmd.Flags().BoolVarP(&cfg.Yes, "yes", "y", false, "Do not ask for confirmation")
+	rootCmd.Flags().BoolVarP(&cfg.DryRun, "dry-run", "n", false, "Only print the next version")
+	rootCmd.Flags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.MarkFlagsMutuallyExclusive("bump", "version")
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func tagRelease(cmd *cobra.Command, args []string) {
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		log.Fatal(red("Not a Git repository, or no commits yet."))
+	}
+	if cfg.Path == "" {
+		cfg.Path = defaultPath()
+	}
+	if cfg.Path != "" {
+		logVerbose(fmt.Sprintf("Counting commits under %s", cfg.Path))
+	}
+
+	releases := findReleases(true)
+	latest := release{}
+	if len(releases) > 0 {
+		latest = releases[0]
+	}
+	// Keep the style of the existing tags: v1.2.3 or 1.2.3
+	cfg.VPrefix = "v"
+	if latest.Tag != "" && !strings.HasPrefix(strings.TrimPrefix(latest.Tag, cfg.Prefix), "v") {
+		cfg.VPrefix = ""
+	}
+
+	commits, err := commitsSince(latest.Tag)
+	if err != nil {
+		log.Fatalf(red("Failed to read the commits: %v"), err)
+	}
+	if latest.Tag == "" {
+		fmt.Printf("No %sv* release tags yet; %d commit(s) in total\n", cfg.Prefix, len(commits))
+	} else {
+		fmt.Printf("Last release: %s, %d commit(s) since\n", latest.Tag, len(commits))
+	}
+	// A pre-release can be promoted to a release without new commits
+	promote := len(latest.Version.Pre) > 0 && cfg.Pre == ""
+	if len(commits) == 0 && cfg.Version == "" && !promote {
+		fmt.Println(green("✓ Nothing to release"))
+		return
+	}
+
+	next := nextVersion(releases)
+	tag := tagName(next)
+	if latest.Tag != "" && next.compare(latest.Version) <= 0 {
+		log.Fatalf(red("%s is not newer than %s"), next, latest.Tag)
+	}
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err == nil {
+		log.Fatalf(red("Tag %s already exists"), tag)
+	}
+
+	head, _ := gitOutput("rev-parse", "--short", "HEAD")
+	fmt.Printf("Next release: %s\n", green(tag))
+	if cfg.DryRun {
+		return
+	}
+	if dirty, _ := gitOutput("status", "--porcelain", "--untracked-files=no"); dirty != "" {
+		fmt.Println(yellow("Warning: you have uncommitted changes; they will not be part of the release."))
+	}
+	kind := "annotated"
+	if cfg.Sign {
+		kind = "signed"
+	}
+	if !confirm(fmt.Sprintf("Create the %s tag %s on %s?", kind, tag, head)) {
+		fmt.Println("Aborted.")
+		return
+	}
+
+	// A release lists everything since the previous release, including what
+	// its pre-releases had
+	stable, _ := latestStable(releases)
+	if len(next.Pre) == 0 && stable.Tag != latest.Tag {
+		if since, err := commitsSince(stable.Tag); err == nil {
+			commits = since
+		}
+	}
+	tagArgs := []string{"tag", "-a", "-m", tagMessage(tag, commits), tag, "HEAD"}
+	if cfg.Sign {
+		tagArgs[1] = "-s"
+	}
+	if err := gitPassthrough(tagArgs...); err != nil {
+		log.Fatalf(red("Failed to create the tag: %v"), err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Tagged %s as %s", head, tag)))
+
+	if !cfg.Push {
+		fmt.Printf("Push it with: git push %s %s\n", cfg.Remote, tag)
+		return
+	}
+	if err := gitPassthrough("push", cfg.Remote, "refs/tags/"+tag); err != nil {
+		log.Fatalf(red("Failed to push %s: %v"), tag, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Pushed %s to %s", tag, cfg.Remote)))
+}
+
+// nextVersion works out the version to release from the flags and the
+// commits since the last stable release
+func nextVersion(releases []release) version {
+	if cfg.Version != "" {
+		v, ok := parseVersion(cfg.Version)
+		if !ok {
+			log.Fatalf(red("%q is not a semantic version"), cfg.Version)
+		}
+		if cfg.Pre != "" {
+			return nextPrerelease(v.stable(), cfg.Pre, findReleases(false))
+		}
+		return v
+	}
+
+	base := release{}
+	if stable, ok := latestStable(releases); ok {
+		base = stable
+	}
+	commits, err := commitsSince(base.Tag)
+	if err != nil {
+		log.Fatalf(red("Failed to read the commits: %v"), err)
+	}
+
+	level := bumpFor(commits)
+	reason := describeCommits(commits)
+	if cfg.Bump != "" {
+		level = -1
+		for l, name := range bumpNames {
+			if name == cfg.Bump && l != bumpNone {
+				level = l
+			}
+		}
+		if level < 0 {
+			log.Fatalf(red("Unknown bump %q; use major, minor or patch"), cfg.Bump)
+		}
+		reason = "--bump " + cfg.Bump
+	}
+	if level == bumpNone {
+		log.Fatalf(red("No feat, fix or breaking commits since %s (%s); use --bump to release anyway"), baseName(base), reason)
+	}
+
+	next := base.Version.bump(level)
+	fmt.Printf("Bump: %s (%s since %s)\n", bumpNames[level], reason, baseName(base))
+	if cfg.Pre != "" {
+		next = nextPrerelease(next, cfg.Pre, findReleases(false))
+	}
+	return next
+}
+
+func baseName(r release) string {
+	if r.Tag == "" {
+		return "the first commit"
+	}
+	return r.Tag
+}
+
+// confirm asks a yes/no question unless --yes was given
+func confirm(message string) bool {
+	if cfg.Yes {
+		return true
+	}
+	answer := false
+	if err := survey.AskOne(&survey.Confirm{Message: message}, &answer); err != nil {
+		return false
+	}
+	return answer
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// gitOutput runs a git command and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logVerbose("git " + strings.Join(args, " "))
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitPassthrough runs a git command attached to the terminal
+func gitPassthrough(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	logVerbose("git " + strings.Join(args, " "))
+	return cmd.Run()
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow("→"), message)
+	}
+}
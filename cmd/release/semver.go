@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version bumps, from smallest to largest
+const (
+	bumpNone = iota
+	bumpPatch
+	bumpMinor
+	bumpMajor
+)
+
+var bumpNames = map[int]string{bumpNone: "none", bumpPatch: "patch", bumpMinor: "minor", bumpMajor: "major"}
+
+// semverPattern matches MAJOR.MINOR.PATCH with optional pre-release and build
+// metadata, and an optional leading "v"
+var semverPattern = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// version is a semantic version; build metadata is dropped as it plays no
+// part in ordering
+type version struct {
+	Major, Minor, Patch int
+	Pre                 []string // pre-release identifiers, e.g. ["rc", "1"]
+}
+
+func parseVersion(s string) (version, bool) {
+	match := semverPattern.FindStringSubmatch(s)
+	if match == nil {
+		return version{}, false
+	}
+	v := version{}
+	v.Major, _ = strconv.Atoi(match[2])
+	v.Minor, _ = strconv.Atoi(match[3])
+	v.Patch, _ = strconv.Atoi(match[4])
+	if match[5] != "" {
+		v.Pre = strings.Split(match[5], ".")
+	}
+	return v, true
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	return s
+}
+
+// stable is the version without its pre-release part
+func (v version) stable() version {
+	return version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+func (v version) bump(level int) version {
+	switch level {
+	case bumpMajor:
+		return version{Major: v.Major + 1}
+	case bumpMinor:
+		return version{Major: v.Major, Minor: v.Minor + 1}
+	case bumpPatch:
+		return version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return v.stable()
+}
+
+// compare orders versions by semver precedence: -1, 0 or 1
+func (v version) compare(o version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	// A pre-release comes before the release itself
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		if c := compareIdentifier(v.Pre[i], o.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Pre) - len(o.Pre))
+}
+
+// compareIdentifier compares pre-release identifiers: numbers numerically
+// and before words, words in ASCII order
+func compareIdentifier(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want version
+		ok   bool
+	}{
+		{"1.2.3", version{Major: 1, Minor: 2, Patch: 3}, true},
+		{"v0.10.0", version{Minor: 10}, true},
+		{"2.0.0-rc.1", version{Major: 2, Pre: []string{"rc", "1"}}, true},
+		{"1.0.0+build.5", version{Major: 1}, true},
+		{"1.0.0-beta+exp.sha.5114f85", version{Major: 1, Pre: []string{"beta"}}, true},
+		{"01.2.3", version{}, false},
+		{"1.2", version{}, false},
+		{"release-1.2.3", version{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVersion(tt.in)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseVersion(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Each version sorts before the next, as in the semver specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "1.10.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := parseVersion(ordered[i])
+			b, _ := parseVersion(ordered[j])
+			if got, want := a.compare(b), sign(i-j); got != want {
+				t.Errorf("%s compare %s = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	a, _ := parseVersion("v1.2.3+build.1")
+	b, _ := parseVersion("1.2.3+build.2")
+	if got := a.compare(b); got != 0 {
+		t.Errorf("versions differing only in prefix and build metadata compare %d, want 0", got)
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	v, _ := parseVersion("1.2.3-rc.1")
+	tests := []struct {
+		level int
+		want  string
+	}{
+		{bumpMajor, "2.0.0"},
+		{bumpMinor, "1.3.0"},
+		{bumpPatch, "1.2.4"},
+		{bumpNone, "1.2.3"},
+	}
+	for _, tt := range tests {
+		if got := v.bump(tt.level).String(); got != tt.want {
+			t.Errorf("bump %s of %s = %s, want %s", bumpNames[tt.level], v, got, tt.want)
+		}
+	}
+}
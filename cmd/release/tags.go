@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/amanmehtacode/GitNoob/internal/conventional"
+)
+
+// release is a tagged version
+type release struct {
+	Tag     string
+	Version version
+}
+
+// commit is one commit since the last release
+type commit struct {
+	Short   string
+	Subject string
+	conventional.Message
+}
+
+// tagName is the tag for a version, e.g. service-a/v1.2.3
+func tagName(v version) string {
+	return cfg.Prefix + cfg.VPrefix + v.String()
+}
+
+// findReleases returns the releases under --prefix, newest first; with
+// merged only those reachable from HEAD
+func findReleases(merged bool) []release {
+	args := []string{"tag", "--list", cfg.Prefix + "*"}
+	if merged {
+		args = append(args, "--merged", "HEAD")
+	}
+	output, err := gitOutput(args...)
+	if err != nil {
+		return nil
+	}
+	var releases []release
+	for _, tag := range nonEmptyLines(output) {
+		rest := strings.TrimPrefix(tag, cfg.Prefix)
+		// service-a/v1.2.3 must not match a prefix of "service-"
+		if strings.Contains(rest, "/") {
+			continue
+		}
+		if v, ok := parseVersion(rest); ok {
+			releases = append(releases, release{Tag: tag, Version: v})
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool { return releases[i].Version.compare(releases[j].Version) > 0 })
+	return releases
+}
+
+// latestStable returns the newest release that is not a pre-release
+func latestStable(releases []release) (release, bool) {
+	for _, r := range releases {
+		if len(r.Version.Pre) == 0 {
+			return r, true
+		}
+	}
+	return release{}, false
+}
+
+// commitsSince reads the commits after a tag, or all of them when tag is
+// empty, limited to --path in a monorepo
+func commitsSince(tag string) ([]commit, error) {
+	revisions := "HEAD"
+	if tag != "" {
+		revisions = tag + "..HEAD"
+	}
+	args := []string{"log", "--no-merges", "--format=%h%x1f%s%x1f%b%x1e", revisions}
+	if cfg.Path != "" {
+		args = append(args, "--", cfg.Path)
+	}
+	output, err := gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []commit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, commit{Short: fields[0], Subject: fields[1], Message: conventional.Parse(fields[1], fields[2])})
+	}
+	return commits, nil
+}
+
+// bumpFor is the bump the commits call for: major for breaking changes,
+// minor for features, patch for fixes and performance work
+func bumpFor(commits []commit) int {
+	level := bumpNone
+	for _, c := range commits {
+		switch {
+		case c.Breaking:
+			return bumpMajor
+		case c.Type == "feat":
+			level = max(level, bumpMinor)
+		case c.Type == "fix" || c.Type == "perf":
+			level = max(level, bumpPatch)
+		}
+	}
+	return level
+}
+
+// describeCommits counts the commits that decided the bump
+func describeCommits(commits []commit) string {
+	var breaking, features, fixes int
+	for _, c := range commits {
+		switch {
+		case c.Breaking:
+			breaking++
+		case c.Type == "feat":
+			features++
+		case c.Type == "fix" || c.Type == "perf":
+			fixes++
+		}
+	}
+	return fmt.Sprintf("%d breaking change(s), %d feature(s), %d fix(es)", breaking, features, fixes)
+}
+
+// nextPrerelease numbers a pre-release of next after the existing ones with
+// the same identifier, e.g. 1.3.0-rc.3 after 1.3.0-rc.2
+func nextPrerelease(next version, id string, releases []release) version {
+	n := 0
+	for _, r := range releases {
+		v := r.Version
+		if v.stable().compare(next) != 0 || len(v.Pre) != 2 || v.Pre[0] != id {
+			continue
+		}
+		var k int
+		if _, err := fmt.Sscanf(v.Pre[1], "%d", &k); err == nil && k > n {
+			n = k
+		}
+	}
+	next.Pre = []string{id, fmt.Sprint(n + 1)}
+	return next
+}
+
+// defaultPath guesses the directory of a monorepo package from its tag
+// prefix: service-a/ means service-a when that directory exists
+func defaultPath() string {
+	dir := strings.TrimSuffix(cfg.Prefix, "/")
+	if dir == "" || dir == cfg.Prefix {
+		return ""
+	}
+	top, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(top + "/" + dir); err == nil && info.IsDir() {
+		return ":/" + dir
+	}
+	return ""
+}
+
+// tagMessage is the annotation of the release tag: the version and the
+// subjects of the commits in it
+func tagMessage(tag string, commits []commit) string {
+	lines := []string{"Release " + tag, ""}
+	for _, c := range commits {
+		lines = append(lines, "- "+c.Subject)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
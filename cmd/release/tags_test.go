@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/amanmehtacode/GitNoob/internal/conventional"
+)
+
+func commitsOf(subjects ...string) []commit {
+	var commits []commit
+	for _, s := range subjects {
+		commits = append(commits, commit{Subject: s, Message: conventional.Parse(s, "")})
+	}
+	return commits
+}
+
+func TestBumpFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits []commit
+		want    int
+	}{
+		{"no commits", nil, bumpNone},
+		{"chores only", commitsOf("chore: tidy", "docs: typo", "Update readme"), bumpNone},
+		{"fix", commitsOf("docs: typo", "fix: off by one"), bumpPatch},
+		{"perf", commitsOf("perf: cache lookups"), bumpPatch},
+		{"feature beats fix", commitsOf("fix: a", "feat: b", "fix: c"), bumpMinor},
+		{"breaking mark", commitsOf("feat: b", "refactor!: drop old api"), bumpMajor},
+		{
+			"breaking footer",
+			[]commit{{Message: conventional.Parse("fix: rename keys", "BREAKING CHANGE: old keys are gone")}},
+			bumpMajor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bumpFor(tt.commits); got != tt.want {
+				t.Errorf("bumpFor = %s, want %s", bumpNames[got], bumpNames[tt.want])
+			}
+		})
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	releasesOf := func(tags ...string) []release {
+		var releases []release
+		for _, tag := range tags {
+			v, _ := parseVersion(tag)
+			releases = append(releases, release{Tag: "v" + tag, Version: v})
+		}
+		return releases
+	}
+	next := version{Major: 1, Minor: 3}
+	tests := []struct {
+		name     string
+		id       string
+		releases []release
+		want     string
+	}{
+		{"first", "rc", nil, "1.3.0-rc.1"},
+		{"after the highest", "rc", releasesOf("1.3.0-rc.2", "1.3.0-rc.10", "1.3.0-rc.1"), "1.3.0-rc.11"},
+		{"other identifiers", "beta", releasesOf("1.3.0-rc.4", "1.3.0-beta.1"), "1.3.0-beta.2"},
+		{"other versions", "rc", releasesOf("1.2.0-rc.5", "1.4.0-rc.2", "1.2.0"), "1.3.0-rc.1"},
+		{"unnumbered", "rc", releasesOf("1.3.0-rc"), "1.3.0-rc.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPrerelease(next, tt.id, tt.releases).String(); got != tt.want {
+				t.Errorf("nextPrerelease = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
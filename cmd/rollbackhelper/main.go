@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/amanmehtacode/GitNoob/internal/journal"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Yes         bool
+	Revert      bool
+	Reset       bool
+	Force       bool
+	Limit       int
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "rollbackhelper",
+		Short: "Undo a recent GitNoob operation",
+		Long: `Undo a recent autocommit, autobranch, automerge, lazypush or gitflowhelper
+operation.
+
+Those commands record the branches and HEAD before they change anything.
+Undoing moves the branches back and checks out the branch you were on. When
+the changes were already pushed, a revert commit is offered instead of a
+force-push. Without a journal, the current branch's reflog is used instead.`,
+		Args: cobra.NoArgs,
+		Run:  chooseAndUndo,
+	}
+
+	// Command-line flags
+	rootCmd.PersistentFlags().BoolVarP(&cfg.Yes, "yes", "y", false, "Do not ask for confirmation")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Revert, "revert", false, "Undo pushed changes with revert commits")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Reset, "reset", false, "Undo pushed changes by moving the branch back anyway (needs a force-push)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Force, "force", false, "Undo even if the branches changed again after the operation")
+	rootCmd.PersistentFlags().IntVarP(&cfg.Limit, "limit", "n", 15, "Number of operations or reflog entries to list")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.MarkFlagsMutuallyExclusive("revert", "reset")
+	rootCmd.AddCommand(newListCmd(), newUndoCmd(), newTimelineCmd(), newRestoreCmd(), newRecoverCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List recent operations that can be undone",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			entries := loadEntries()
+			if len(entries) == 0 {
+				fmt.Println(yellow("No GitNoob operations recorded here yet. Recent HEAD reflog:"))
+				for _, r := range loadReflog(cfg.Limit) {
+					fmt.Printf("  %s\n", r.summary())
+				}
+				return
+			}
+			for i, e := range entries {
+				fmt.Printf("%3d  %s\n", i+1, entrySummary(e))
+			}
+		},
+	}
+}
+
+func newUndoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [number]",
+		Short: "Undo an operation by its number in the list (default: the most recent)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			entries := loadEntries()
+			if len(entries) == 0 {
+				fmt.Println(yellow("No GitNoob operations recorded here; falling back to the reflog."))
+				undoFromReflog(1)
+				return
+			}
+
+			index := 1
+			if len(args) == 1 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 1 || n > len(entries) {
+					log.Fatalf(red("Invalid operation number %q; see rollbackhelper list"), args[0])
+				}
+				index = n
+			}
+			undoEntry(entries[index-1])
+		},
+	}
+}
+
+// chooseAndUndo lets the user pick an operation to undo
+func chooseAndUndo(cmd *cobra.Command, args []string) {
+	entries := loadEntries()
+	if len(entries) == 0 {
+		fmt.Println(yellow("No GitNoob operations recorded here; falling back to the reflog."))
+		undoFromReflog(1)
+		return
+	}
+
+	options := make([]string, len(entries))
+	for i, e := range entries {
+		options[i] = entrySummary(e)
+	}
+	var picked int
+	if err := survey.AskOne(&survey.Select{Message: "Select an operation to undo:", Options: options, PageSize: 15}, &picked); err != nil {
+		log.Fatalf(red("Selection cancelled: %v"), err)
+	}
+	undoEntry(entries[picked])
+}
+
+// loadEntries returns the most recent journal entries, up to the list limit
+func loadEntries() []journal.Entry {
+	entries, err := journal.Load()
+	if err != nil {
+		log.Fatalf(red("Failed to read the operation journal: %v"), err)
+	}
+	if cfg.Limit > 0 && len(entries) > cfg.Limit {
+		entries = entries[:cfg.Limit]
+	}
+	return entries
+}
+
+func entrySummary(e journal.Entry) string {
+	state := ""
+	switch {
+	case e.Undone:
+		state = " " + yellow("(undone)")
+	case !e.Complete():
+		state = " " + red("(did not finish)")
+	case e.Error != "":
+		state = " " + red("(failed)")
+	}
+	return fmt.Sprintf("%-8s %-12s %s%s", age(e.Time), e.Command, e.Description, state)
+}
+
+func confirm(message string) bool {
+	if cfg.Yes {
+		return true
+	}
+	answer := false
+	if err := survey.AskOne(&survey.Confirm{Message: message}, &answer); err != nil {
+		return false
+	}
+	return answer
+}
+
+func age(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// gitOutput runs a git command and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitPassthrough runs a git command attached to the terminal
+func gitPassthrough(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
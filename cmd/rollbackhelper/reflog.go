@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reflogEntry is one step in the history of HEAD or a branch
+type reflogEntry struct {
+	Selector string // HEAD@{n} or <branch>@{n}
+	Hash     string
+	Action   string // commit, checkout, reset, merge, ...
+	Message  string
+	Time     time.Time
+	Subject  string // subject of the commit HEAD pointed to
+}
+
+func (r reflogEntry) summary() string {
+	return fmt.Sprintf("%-9s %-8s %s %-12s %s", r.Selector, age(r.Time), shortHash(r.Hash), r.Action, r.Message)
+}
+
+// loadReflog reads the most recent HEAD reflog entries
+func loadReflog(limit int) []reflogEntry {
+	return loadReflogOf("HEAD", limit)
+}
+
+// loadReflogOf reads the most recent reflog entries of HEAD or a branch
+func loadReflogOf(ref string, limit int) []reflogEntry {
+	fullRef := ref
+	if ref != "HEAD" {
+		fullRef = "refs/heads/" + ref
+	}
+	output, err := gitOutput("reflog", "show", "--date=unix", "--format=%gd%x00%H%x00%gs%x00%s", "-n", strconv.Itoa(limit), fullRef)
+	if err != nil {
+		return nil
+	}
+
+	var entries []reflogEntry
+	for i, line := range nonEmptyLines(output) {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		// With --date=unix the selector carries the entry's timestamp
+		stamp := strings.TrimSuffix(fields[0][strings.Index(fields[0], "{")+1:], "}")
+		seconds, _ := strconv.ParseInt(stamp, 10, 64)
+		action, message, ok := strings.Cut(fields[2], ": ")
+		if !ok {
+			action, message = "", fields[2]
+		}
+		entries = append(entries, reflogEntry{
+			Selector: fmt.Sprintf("%s@{%d}", ref, i),
+			Hash:     fields[1],
+			Action:   action,
+			Message:  message,
+			Time:     time.Unix(seconds, 0),
+			Subject:  fields[3],
+		})
+	}
+	return entries
+}
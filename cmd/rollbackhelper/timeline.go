@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/amanmehtacode/GitNoob/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+var timelineList bool
+
+func newTimelineCmd() *cobra.Command {
+	timelineCmd := &cobra.Command{
+		Use:     "timeline [branch]",
+		Aliases: []string{"reflog"},
+		Short:   "Browse the reflog of HEAD or a branch and go back to any point",
+		Long: `Show where HEAD (or a branch) has been as a timeline, preview the tree at any
+point, and restore the branch to that point or create a recovery branch from it.
+
+Commits that no branch contains any more are marked, which is where work lost
+to a bad reset or rebase usually turns up.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  timeline,
+	}
+	timelineCmd.Flags().BoolVarP(&timelineList, "list", "l", false, "Only print the timeline")
+	return timelineCmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <HEAD@{n}|branch@{n}>",
+		Short: "Move the branch back to a point in its reflog",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ref, entry := findReflogEntry(args[0])
+			restoreTo(ref, entry)
+		},
+	}
+}
+
+func newRecoverCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "recover <HEAD@{n}|branch@{n}> <new-branch>",
+		Short: "Create a branch at a point in the reflog",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			_, entry := findReflogEntry(args[0])
+			recoverBranch(entry, args[1])
+		},
+	}
+}
+
+// timeline prints the reflog and, interactively, acts on a chosen point
+func timeline(cmd *cobra.Command, args []string) {
+	ref := "HEAD"
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	entries := loadReflogOf(ref, cfg.Limit)
+	if len(entries) == 0 {
+		log.Fatalf(red("No reflog for %s"), ref)
+	}
+
+	if timelineList {
+		printTimeline(entries)
+		return
+	}
+
+	for {
+		options := make([]string, len(entries))
+		for i, e := range entries {
+			options[i] = timelineRow(e, true)
+		}
+		var picked int
+		prompt := &survey.Select{Message: fmt.Sprintf("Timeline of %s, newest first:", ref), Options: options, PageSize: 15}
+		if err := survey.AskOne(prompt, &picked); err != nil {
+			return
+		}
+		if done := actOnPoint(ref, entries[picked]); done {
+			return
+		}
+	}
+}
+
+// printTimeline groups reflog entries by day
+func printTimeline(entries []reflogEntry) {
+	day := ""
+	for _, e := range entries {
+		if heading := dayHeading(e.Time); heading != day {
+			day = heading
+			fmt.Println(yellow(day))
+		}
+		fmt.Printf("  %s\n", timelineRow(e, false))
+	}
+}
+
+// timelineRow describes one point: when, what happened and the commit HEAD was on
+func timelineRow(e reflogEntry, withDate bool) string {
+	when := e.Time.Format("15:04")
+	if withDate {
+		when = e.Time.Format("Jan _2 15:04")
+	}
+	row := fmt.Sprintf("%-10s %s  %-12s %-32s %s %s", e.Selector, when, e.Action, truncate(e.Message, 32), shortHash(e.Hash), e.Subject)
+	if !onAnyBranch(e.Hash) {
+		row += " " + red("[not on any branch]")
+	}
+	return row
+}
+
+func dayHeading(t time.Time) string {
+	now := time.Now()
+	y, m, d := t.Date()
+	switch {
+	case y == now.Year() && m == now.Month() && d == now.Day():
+		return "Today"
+	case now.Sub(t) < 48*time.Hour && now.AddDate(0, 0, -1).Day() == d:
+		return "Yesterday"
+	}
+	return t.Format("Mon Jan 2 2006")
+}
+
+// actOnPoint offers what can be done with one reflog point; it reports
+// whether the timeline should close
+func actOnPoint(ref string, e reflogEntry) bool {
+	target := ref
+	if ref == "HEAD" {
+		target = currentBranch()
+		if target == "" {
+			target = "HEAD"
+		}
+	}
+	for {
+		actions := []string{
+			"Show the commit",
+			"Compare with now",
+			"View a file at this point",
+			fmt.Sprintf("Restore %s to this point", target),
+			"Create a recovery branch here",
+			"Back to the timeline",
+		}
+		var action int
+		if err := survey.AskOne(&survey.Select{Message: fmt.Sprintf("%s (%s %s):", e.Selector, shortHash(e.Hash), e.Subject), Options: actions}, &action); err != nil {
+			return true
+		}
+		switch action {
+		case 0:
+			gitPassthrough("show", "--stat", "--format=fuller", e.Hash)
+		case 1:
+			fmt.Println(yellow(fmt.Sprintf("→ Changes from now (HEAD) to %s", e.Selector)))
+			gitPassthrough("diff", "--stat", "HEAD", e.Hash)
+		case 2:
+			viewFile(e)
+		case 3:
+			restoreTo(ref, e)
+			return true
+		case 4:
+			var name string
+			if err := survey.AskOne(&survey.Input{Message: "Branch name:", Default: "recovered/" + shortHash(e.Hash)}, &name, survey.WithValidator(survey.Required)); err != nil {
+				continue
+			}
+			recoverBranch(e, strings.TrimSpace(name))
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// viewFile prints one file as it was at a reflog point
+func viewFile(e reflogEntry) {
+	output, err := gitOutput("ls-tree", "-r", "--name-only", e.Hash)
+	if err != nil {
+		logError("Failed to list files", err)
+		return
+	}
+	files := nonEmptyLines(output)
+	if len(files) == 0 {
+		fmt.Println(yellow("No files at this point."))
+		return
+	}
+	var path string
+	if err := survey.AskOne(&survey.Select{Message: "File:", Options: files, PageSize: 15}, &path); err != nil {
+		return
+	}
+	gitPassthrough("show", e.Hash+":"+path)
+}
+
+// findReflogEntry resolves HEAD@{n} or branch@{n} to its reflog entry
+func findReflogEntry(selector string) (string, reflogEntry) {
+	ref, rest, ok := strings.Cut(selector, "@{")
+	var n int
+	if !ok || ref == "" {
+		log.Fatalf(red("Invalid reflog point %q; use HEAD@{n} or <branch>@{n}"), selector)
+	}
+	if _, err := fmt.Sscanf(rest, "%d}", &n); err != nil {
+		log.Fatalf(red("Invalid reflog point %q; use HEAD@{n} or <branch>@{n}"), selector)
+	}
+	entries := loadReflogOf(ref, n+1)
+	if len(entries) <= n {
+		log.Fatalf(red("%s has no reflog entry %d"), ref, n)
+	}
+	return ref, entries[n]
+}
+
+// restoreTo moves a branch (or the checked-out branch for HEAD) to a reflog point.
+// Commits it leaves behind stay in the reflog, so this can be undone as well.
+func restoreTo(ref string, e reflogEntry) {
+	branch := ref
+	if ref == "HEAD" {
+		branch = currentBranch()
+	}
+	tip := "HEAD"
+	if branch != "" {
+		tip = branch
+	}
+
+	output, _ := gitOutput("log", "--oneline", "--no-decorate", e.Hash+".."+tip)
+	if left := nonEmptyLines(output); len(left) > 0 {
+		fmt.Println(yellow(fmt.Sprintf("→ %s will no longer contain %d commit(s) (they stay in the reflog):", tip, len(left))))
+		for _, line := range left {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	if !confirm(fmt.Sprintf("Move %s to %s (%s)?", tip, e.Selector, shortHash(e.Hash))) {
+		fmt.Println(yellow("Nothing changed."))
+		return
+	}
+
+	record, err := journal.Begin("rollbackhelper", fmt.Sprintf("restore %s to %s", tip, e.Selector))
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not record the restore: %v", err))
+	}
+	defer record.Finish()
+
+	switch {
+	case branch == "":
+		_, err = gitOutput("checkout", "--quiet", "--detach", e.Hash)
+	case branch == currentBranch():
+		_, err = gitOutput("reset", "--keep", e.Hash)
+	default:
+		_, err = gitOutput("update-ref", "-m", "rollbackhelper: restore to "+e.Selector, "refs/heads/"+branch, e.Hash)
+	}
+	if err != nil {
+		log.Fatalf(red("Failed to restore %s: %v\nCommit or stash local changes that conflict, then try again."), tip, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ %s is back at %s (%s). ⏪", tip, shortHash(e.Hash), e.Subject)))
+}
+
+// recoverBranch creates a branch at a reflog point without touching the current one
+func recoverBranch(e reflogEntry, name string) {
+	if _, err := gitOutput("branch", name, e.Hash); err != nil {
+		log.Fatalf(red("Failed to create %s: %v"), name, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Created %s at %s (%s). 🛟", name, shortHash(e.Hash), e.Subject)))
+}
+
+// onAnyBranch reports whether a local branch still contains the commit
+func onAnyBranch(hash string) bool {
+	output, _ := gitOutput("for-each-ref", "--count=1", "--contains", hash, "refs/heads")
+	return output != ""
+}
+
+func truncate(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/amanmehtacode/GitNoob/internal/journal"
+)
+
+// refChange moves one branch back to where it was before an operation
+type refChange struct {
+	Branch  string
+	From    string   // current commit, empty if the operation deleted the branch
+	To      string   // commit before the operation, empty if the operation created the branch
+	Drifted bool     // the branch moved again after the operation finished
+	Commits []string // one-line summaries of the commits being undone
+	Pushed  bool     // some of those commits are on a remote
+}
+
+func (c refChange) String() string {
+	switch {
+	case c.To == "":
+		return fmt.Sprintf("%s: delete (created at %s)", c.Branch, shortHash(c.From))
+	case c.From == "":
+		return fmt.Sprintf("%s: recreate at %s", c.Branch, shortHash(c.To))
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Branch, shortHash(c.From), shortHash(c.To))
+}
+
+// planUndo compares the journal entry with the repository as it is now
+func planUndo(e journal.Entry) ([]refChange, error) {
+	current, err := journal.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range e.Before.Refs {
+		names[name] = true
+	}
+	for name := range current.Refs {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []refChange
+	for _, name := range sorted {
+		before, had := e.Before.Refs[name]
+		now, has := current.Refs[name]
+		if had == has && before == now {
+			continue
+		}
+
+		drifted := false
+		if e.Complete() {
+			after, afterHas := e.After.Refs[name]
+			if had == afterHas && before == after {
+				// The operation did not touch this branch
+				continue
+			}
+			drifted = after != now || afterHas != has
+		}
+
+		c := refChange{Branch: name, From: now, To: before, Drifted: drifted}
+		if has && had {
+			output, _ := gitOutput("log", "--oneline", "--no-decorate", "--first-parent", before+".."+now)
+			c.Commits = nonEmptyLines(output)
+			c.Pushed = pushed(now, before)
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// pushed reports whether any commit in to..from is on a remote-tracking branch
+func pushed(from, to string) bool {
+	total, _ := gitOutput("rev-list", "--count", to+".."+from)
+	local, _ := gitOutput("rev-list", "--count", to+".."+from, "--not", "--remotes")
+	return total != local
+}
+
+// undoEntry restores the branches recorded before an operation
+func undoEntry(e journal.Entry) {
+	fmt.Println(yellow(fmt.Sprintf("→ Undoing %s: %s (%s)", e.Command, e.Description, age(e.Time))))
+	if e.Undone && !confirm("This operation was already undone. Undo it again?") {
+		return
+	}
+	if !e.Complete() {
+		fmt.Println(yellow("  The operation did not finish, so every change to these branches since it started will be undone."))
+	}
+	ensureNoOperationInProgress()
+
+	changes, err := planUndo(e)
+	if err != nil {
+		log.Fatalf(red("Failed to read the repository state: %v"), err)
+	}
+	restoreHead := needsCheckout(e.Before)
+	if len(changes) == 0 && !restoreHead {
+		fmt.Println(green("✓ Nothing to undo: the branches are already where they were."))
+		return
+	}
+
+	drifted, anyPushed := printPlan(changes)
+	if restoreHead {
+		fmt.Printf("  check out %s\n", describeHead(e.Before))
+	}
+	if drifted && !cfg.Force {
+		log.Fatal(red("Some branches changed after the operation. Rerun with --force to undo anyway; the later commits listed above would be undone too."))
+	}
+
+	revert := anyPushed && chooseRevert()
+	if !confirm("Undo this operation?") {
+		fmt.Println(yellow("Nothing changed."))
+		return
+	}
+
+	record, err := journal.Begin("rollbackhelper", fmt.Sprintf("undo %s: %s", e.Command, e.Description))
+	if err != nil {
+		logVerbose(fmt.Sprintf("Could not record the undo: %v", err))
+	}
+	defer record.Finish()
+
+	mode := e.UndoMode
+	if mode == "" || restoreHead {
+		mode = journal.UndoKeep
+	}
+	created, staged := applyChanges(changes, revert, mode)
+
+	if restoreHead {
+		if err := checkout(e.Before); err != nil {
+			log.Fatalf(red("Branches were restored but checking out %s failed: %v"), describeHead(e.Before), err)
+		}
+	}
+	if created != nil {
+		// The branch the operation created was checked out until now
+		if err := moveRef(*created); err != nil {
+			logError(fmt.Sprintf("Failed to delete %s", created.Branch), err)
+		} else {
+			fmt.Println(green("✓ " + created.String()))
+		}
+	}
+	if err := e.MarkUndone(); err != nil {
+		logVerbose(fmt.Sprintf("Could not mark the operation as undone: %v", err))
+	}
+
+	fmt.Println(green("✓ Operation undone. ⏪"))
+	if staged && mode == journal.UndoSoft {
+		fmt.Println(yellow("  The changes from the undone commit(s) are staged again."))
+	}
+	if revert {
+		fmt.Println(yellow("  Push the revert commit(s) with git push."))
+	} else if anyPushed {
+		fmt.Println(yellow("  The undone commits are still on the remote; remove them with git push --force-with-lease."))
+	}
+}
+
+// printPlan shows every branch change and reports drift and pushed commits
+func printPlan(changes []refChange) (bool, bool) {
+	drifted, anyPushed := false, false
+	for _, c := range changes {
+		line := "  " + c.String()
+		if c.Drifted {
+			line += " " + red("(changed since)")
+			drifted = true
+		}
+		if c.Pushed {
+			line += " " + yellow("(pushed)")
+			anyPushed = true
+		}
+		fmt.Println(line)
+		for _, commit := range c.Commits {
+			fmt.Printf("      undo %s\n", commit)
+		}
+	}
+	return drifted, anyPushed
+}
+
+// chooseRevert asks how to undo commits that are already on a remote
+func chooseRevert() bool {
+	switch {
+	case cfg.Revert:
+		return true
+	case cfg.Reset:
+		return false
+	case cfg.Yes:
+		return true
+	}
+
+	revertOption := "Create revert commit(s) on top (safe for shared branches)"
+	resetOption := "Move the branch back anyway (you will need to force-push)"
+	var answer string
+	prompt := &survey.Select{
+		Message: "Some of these commits were already pushed. How should they be undone?",
+		Options: []string{revertOption, resetOption, "Cancel"},
+	}
+	if err := survey.AskOne(prompt, &answer); err != nil || answer == "Cancel" {
+		fmt.Println(yellow("Nothing changed."))
+		os.Exit(0)
+	}
+	return answer == revertOption
+}
+
+// applyChanges reverts pushed branches when asked and moves the rest back.
+// A created branch that is still checked out is returned for deletion once
+// the original branch has been checked out again, along with whether the
+// checked-out branch was reset.
+func applyChanges(changes []refChange, revert bool, mode string) (*refChange, bool) {
+	start, _ := gitOutput("symbolic-ref", "--quiet", "--short", "HEAD")
+
+	for _, c := range changes {
+		if !revert || !c.Pushed {
+			continue
+		}
+		if c.Branch != currentBranch() {
+			if _, err := gitOutput("checkout", "--quiet", c.Branch); err != nil {
+				log.Fatalf(red("Failed to check out %s to revert it: %v"), c.Branch, err)
+			}
+		}
+		revertRange(c.To, c.From)
+		fmt.Println(green(fmt.Sprintf("✓ Reverted %d commit(s) on %s", len(c.Commits), c.Branch)))
+	}
+	if start != "" && currentBranch() != start {
+		if _, err := gitOutput("checkout", "--quiet", start); err != nil {
+			log.Fatalf(red("Failed to return to %s: %v"), start, err)
+		}
+	}
+
+	var created *refChange
+	reset := false
+	for i, c := range changes {
+		if revert && c.Pushed {
+			continue
+		}
+		if c.Branch == start {
+			if c.To == "" {
+				created = &changes[i]
+				continue
+			}
+			if _, err := gitOutput("reset", "--"+mode, c.To); err != nil {
+				log.Fatalf(red("Failed to move %s back to %s: %v"), c.Branch, shortHash(c.To), err)
+			}
+			reset = true
+		} else if err := moveRef(c); err != nil {
+			log.Fatalf(red("Failed to restore %s: %v"), c.Branch, err)
+		}
+		fmt.Println(green("✓ " + c.String()))
+	}
+	return created, reset
+}
+
+// moveRef updates a branch that is not checked out, guarding against it moving meanwhile
+func moveRef(c refChange) error {
+	ref := "refs/heads/" + c.Branch
+	switch {
+	case c.To == "":
+		_, err := gitOutput("update-ref", "-d", ref, c.From)
+		return err
+	case c.From == "":
+		_, err := gitOutput("update-ref", ref, c.To)
+		return err
+	}
+	_, err := gitOutput("update-ref", ref, c.To, c.From)
+	return err
+}
+
+// revertRange reverts the commits in to..from, newest first, reverting
+// merges against their first parent
+func revertRange(to, from string) {
+	output, _ := gitOutput("rev-list", "--first-parent", to+".."+from)
+	for _, commit := range nonEmptyLines(output) {
+		args := []string{"revert", "--no-edit"}
+		parents, _ := gitOutput("rev-list", "--parents", "-n", "1", commit)
+		if len(strings.Fields(parents)) > 2 {
+			args = append(args, "-m", "1")
+		}
+		if _, err := gitOutput(append(args, commit)...); err != nil {
+			log.Fatalf(red("Reverting %s stopped with conflicts: %v\nResolve them and run git revert --continue, or git revert --abort."), shortHash(commit), err)
+		}
+	}
+}
+
+// needsCheckout reports whether HEAD is somewhere else than before the operation
+func needsCheckout(before journal.State) bool {
+	if before.Branch != "" {
+		return currentBranch() != before.Branch
+	}
+	head, _ := gitOutput("rev-parse", "HEAD")
+	return before.Head != "" && head != before.Head
+}
+
+func checkout(before journal.State) error {
+	if before.Branch != "" {
+		_, err := gitOutput("checkout", "--quiet", before.Branch)
+		return err
+	}
+	_, err := gitOutput("checkout", "--quiet", "--detach", before.Head)
+	return err
+}
+
+func describeHead(state journal.State) string {
+	if state.Branch != "" {
+		return state.Branch
+	}
+	return "detached HEAD at " + shortHash(state.Head)
+}
+
+func currentBranch() string {
+	branch, _ := gitOutput("symbolic-ref", "--quiet", "--short", "HEAD")
+	return branch
+}
+
+// ensureNoOperationInProgress refuses to undo in the middle of a merge, rebase or revert
+func ensureNoOperationInProgress() {
+	for _, ref := range []string{"MERGE_HEAD", "REBASE_HEAD", "REVERT_HEAD", "CHERRY_PICK_HEAD"} {
+		if _, err := gitOutput("rev-parse", "--quiet", "--verify", ref); err == nil {
+			name := strings.ToLower(strings.TrimSuffix(ref, "_HEAD"))
+			log.Fatalf(red("A %s is in progress. Finish it or run git %s --abort first."), strings.ReplaceAll(name, "_", "-"), strings.ReplaceAll(name, "_", "-"))
+		}
+	}
+}
+
+// undoFromReflog moves the current branch back n reflog steps when no journal
+// exists. It reads the branch's own reflog rather than HEAD's, which also
+// records checkouts of other branches; a detached HEAD uses HEAD's.
+func undoFromReflog(n int) {
+	branch := currentBranch()
+	if branch == "" {
+		branch = "HEAD"
+	}
+	entries := loadReflogOf(branch, n+1)
+	if len(entries) <= n {
+		log.Fatalf(red("The reflog of %s has nothing to go back to."), branch)
+	}
+	ensureNoOperationInProgress()
+
+	last, target := entries[0], entries[n]
+	fmt.Printf("  Last action:  %s\n", last.summary())
+	fmt.Printf("  Go back to:   %s\n", target.summary())
+
+	c := refChange{Branch: branch, From: last.Hash, To: target.Hash}
+	output, _ := gitOutput("log", "--oneline", "--no-decorate", "--first-parent", c.To+".."+c.From)
+	c.Commits = nonEmptyLines(output)
+	c.Pushed = pushed(c.From, c.To)
+	printPlan([]refChange{c})
+
+	revert := c.Pushed && chooseRevert()
+	if !confirm(fmt.Sprintf("Move %s back to %s?", branch, target.Selector)) {
+		fmt.Println(yellow("Nothing changed."))
+		return
+	}
+	if revert {
+		revertRange(c.To, c.From)
+		fmt.Println(green(fmt.Sprintf("✓ Reverted %d commit(s). Push them with git push.", len(c.Commits))))
+		return
+	}
+	if _, err := gitOutput("reset", "--keep", target.Hash); err != nil {
+		log.Fatalf(red("Failed to move back to %s: %v"), target.Selector, err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ %s is back at %s. ⏪", branch, shortHash(target.Hash))))
+}
+
+func logError(message string, err error) {
+	log.Printf("%s %s: %v", red("✗"), message, err)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyPop     bool
+	applyIndex   bool
+	saveMessage  string
+	pruneOlder   string
+	pruneDryRun  bool
+	pruneConfirm bool
+)
+
+func newShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <index>",
+		Short: "Preview the diff of a stash",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			previewStash(findStash(args[0]))
+		},
+	}
+}
+
+func newApplyCmd() *cobra.Command {
+	applyCmd := &cobra.Command{
+		Use:   "apply <index>",
+		Short: "Apply a stash, reporting conflicts instead of losing it",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !applyStash(findStash(args[0]), applyPop) {
+				os.Exit(1)
+			}
+		},
+	}
+	applyCmd.Flags().BoolVarP(&applyPop, "pop", "p", false, "Drop the stash after it applies cleanly")
+	applyCmd.Flags().BoolVar(&applyIndex, "index", false, "Also restore which changes were staged")
+	return applyCmd
+}
+
+func newDropCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "drop <index>",
+		Aliases: []string{"delete"},
+		Short:   "Delete a stash",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dropStash(findStash(args[0]))
+		},
+	}
+}
+
+func newRenameCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <index> <message>",
+		Short: "Change the message of a stash",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			renameStash(findStash(args[0]), strings.Join(args[1:], " "))
+		},
+	}
+}
+
+func newSaveCmd() *cobra.Command {
+	saveCmd := &cobra.Command{
+		Use:   "save [path...]",
+		Short: "Stash only the given paths, or pick them from the changed files",
+		Run:   saveStash,
+	}
+	saveCmd.Flags().StringVarP(&saveMessage, "message", "m", "", "Stash message")
+	return saveCmd
+}
+
+func newBranchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "branch <index> <branch-name>",
+		Short: "Create a branch from the commit a stash was made on and apply the stash there",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			stashToBranch(findStash(args[0]), args[1])
+		},
+	}
+}
+
+func newPruneCmd() *cobra.Command {
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Drop every stash older than a threshold",
+		Args:  cobra.NoArgs,
+		Run:   pruneStashes,
+	}
+	pruneCmd.Flags().StringVar(&pruneOlder, "older-than", "30d", "Age threshold, e.g. 12h, 30d or 8w")
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "Only list the stashes that would be dropped")
+	pruneCmd.Flags().BoolVarP(&pruneConfirm, "yes", "y", false, "Do not ask for confirmation")
+	return pruneCmd
+}
+
+// previewStash prints the stash diff, with untracked files when requested
+func previewStash(st stash) {
+	fmt.Println(yellow(fmt.Sprintf("→ %s on %s: %s", st.ref(), st.Branch, st.Message)))
+	if err := gitPassthrough("stash", "show", "--stat", "--patch", "--color=always", st.ref()); err != nil {
+		logError(fmt.Sprintf("Failed to show %s", st.ref()), err)
+		return
+	}
+	if cfg.Untracked && refExists(st.Hash+"^3") {
+		fmt.Println(yellow("→ Untracked files"))
+		if err := gitPassthrough("show", "--stat", "--patch", "--color=always", "--format=", st.Hash+"^3"); err != nil {
+			logError("Failed to show untracked files", err)
+		}
+	}
+}
+
+// applyStash applies or pops a stash and reports whether it applied cleanly.
+// The stash is only dropped after a clean pop, so conflicts never lose it.
+func applyStash(st stash, pop bool) bool {
+	if overlap := locallyModified(stashPaths(st)); len(overlap) > 0 {
+		fmt.Println(red(fmt.Sprintf("✗ %s touches files with uncommitted changes:", st.ref())))
+		for _, path := range overlap {
+			fmt.Printf("  %s\n", path)
+		}
+		fmt.Println(yellow("Commit or stash those changes first."))
+		return false
+	}
+
+	args := []string{"stash", "apply"}
+	if applyIndex {
+		args = append(args, "--index")
+	}
+	output, err := gitOutput(append(args, st.ref())...)
+	logVerbose(output)
+	if err != nil {
+		conflicts, _ := gitOutput("diff", "--name-only", "--diff-filter=U")
+		if conflicts == "" {
+			logError(fmt.Sprintf("Failed to apply %s", st.ref()), err)
+			return false
+		}
+		fmt.Println(red(fmt.Sprintf("✗ %s applied with conflicts in:", st.ref())))
+		for _, path := range nonEmptyLines(conflicts) {
+			fmt.Printf("  %s\n", path)
+		}
+		fmt.Println(yellow(fmt.Sprintf("The stash was kept. Resolve the conflicts, git add the files, then run: stashmanager drop %d", st.Index)))
+		return false
+	}
+
+	if !pop {
+		fmt.Println(green(fmt.Sprintf("✓ Applied %s", st.ref())))
+		return true
+	}
+	if _, err := gitOutput("stash", "drop", st.ref()); err != nil {
+		logError(fmt.Sprintf("Applied but failed to drop %s", st.ref()), err)
+		return true
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Popped %s", st.ref())))
+	return true
+}
+
+// stashPaths lists the tracked and untracked files a stash would write
+func stashPaths(st stash) []string {
+	output, _ := gitOutput("stash", "show", "--name-only", st.ref())
+	paths := nonEmptyLines(output)
+	if refExists(st.Hash + "^3") {
+		output, _ = gitOutput("ls-tree", "-r", "--name-only", st.Hash+"^3")
+		paths = append(paths, nonEmptyLines(output)...)
+	}
+	return paths
+}
+
+// locallyModified returns the paths that have uncommitted changes or exist
+// untracked. The paths are relative to the top of the work tree, as git stash
+// show lists them, whatever directory this runs from.
+func locallyModified(paths []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	var pathspecs []string
+	for _, path := range paths {
+		pathspecs = append(pathspecs, ":(top,literal)"+path)
+	}
+	modified, _ := gitOutput(append([]string{"diff", "--name-only", "HEAD", "--"}, pathspecs...)...)
+	untracked, _ := gitOutput(append([]string{"ls-files", "--others", "--exclude-standard", "--full-name", "--"}, pathspecs...)...)
+	return append(nonEmptyLines(modified), nonEmptyLines(untracked)...)
+}
+
+func dropStash(st stash) {
+	if _, err := gitOutput("stash", "drop", st.ref()); err != nil {
+		log.Fatalf(red("Failed to drop %s: %v"), st.ref(), err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Dropped %s (%s), commit %s", st.ref(), st.Message, st.Hash[:10])))
+}
+
+// renameStash re-stores the stash commit with a new message. Git has no
+// rename, so the stash moves to stash@{0}; the old entry is dropped only
+// once the new one is stored.
+func renameStash(st stash, message string) {
+	subject := message
+	if st.Branch != "-" {
+		subject = fmt.Sprintf("On %s: %s", st.Branch, message)
+	}
+	if _, err := gitOutput("stash", "store", "-m", subject, st.Hash); err != nil {
+		log.Fatalf(red("Failed to rename %s: %v"), st.ref(), err)
+	}
+	// Storing pushed the old entry down by one
+	old := stash{Index: st.Index + 1}
+	if _, err := gitOutput("stash", "drop", old.ref()); err != nil {
+		log.Fatalf(red("Renamed stash stored as stash@{0}, but failed to drop the old %s: %v"), old.ref(), err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Renamed %s to %q, now stash@{0}", st.ref(), message)))
+}
+
+// saveStash stashes the given paths, or the paths picked from the changed files
+func saveStash(cmd *cobra.Command, args []string) {
+	paths := args
+	if len(paths) == 0 {
+		changed := changedPaths()
+		if len(changed) == 0 {
+			fmt.Println(green("✓ No changes to stash."))
+			return
+		}
+		if err := survey.AskOne(&survey.MultiSelect{Message: "Select files to stash:", Options: changed, PageSize: 15}, &paths); err != nil {
+			log.Fatalf(red("Selection cancelled: %v"), err)
+		}
+		if len(paths) == 0 {
+			fmt.Println(yellow("Nothing selected."))
+			return
+		}
+	}
+
+	stashArgs := []string{"stash", "push"}
+	if cfg.Untracked {
+		stashArgs = append(stashArgs, "--include-untracked")
+	}
+	if saveMessage != "" {
+		stashArgs = append(stashArgs, "--message", saveMessage)
+	}
+	stashArgs = append(append(stashArgs, "--"), paths...)
+	if _, err := gitOutput(stashArgs...); err != nil {
+		log.Fatalf(red("Failed to stash: %v"), err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Stashed %d path(s) as stash@{0}", len(paths))))
+}
+
+// changedPaths lists modified files, and untracked ones with --include-untracked
+func changedPaths() []string {
+	modified, _ := gitOutput("diff", "--name-only", "HEAD")
+	paths := nonEmptyLines(modified)
+	if cfg.Untracked {
+		untracked, _ := gitOutput("ls-files", "--others", "--exclude-standard")
+		paths = append(paths, nonEmptyLines(untracked)...)
+	}
+	return paths
+}
+
+// stashToBranch checks out a new branch at the stash's base commit and pops the stash there
+func stashToBranch(st stash, name string) {
+	if _, err := gitOutput("stash", "branch", name, st.ref()); err != nil {
+		log.Fatalf(red("Failed to create branch %s from %s: %v"), name, st.ref(), err)
+	}
+	fmt.Println(green(fmt.Sprintf("✓ Created branch %s from %s and applied it", name, st.ref())))
+}
+
+// pruneStashes drops stashes older than the threshold, newest index last so
+// the remaining indexes stay valid while dropping
+func pruneStashes(cmd *cobra.Command, args []string) {
+	threshold, err := parseAge(pruneOlder)
+	if err != nil {
+		log.Fatal(red(err.Error()))
+	}
+	stashes, err := loadStashes()
+	if err != nil {
+		log.Fatalf(red("Failed to list stashes: %v"), err)
+	}
+
+	var old []stash
+	for _, st := range stashes {
+		if time.Since(st.Created) >= threshold {
+			old = append(old, st)
+		}
+	}
+	if len(old) == 0 {
+		fmt.Println(green(fmt.Sprintf("✓ No stashes older than %s.", pruneOlder)))
+		return
+	}
+
+	fmt.Println(yellow(fmt.Sprintf("→ %d stash(es) older than %s:", len(old), pruneOlder)))
+	for _, st := range old {
+		fmt.Printf("  %s\n", st.summary())
+	}
+	if pruneDryRun || (!pruneConfirm && !confirm(fmt.Sprintf("Drop %d stash(es)?", len(old)))) {
+		return
+	}
+
+	for i := len(old) - 1; i >= 0; i-- {
+		dropStash(old[i])
+	}
+}
+
+// parseAge accepts Go durations plus d (days) and w (weeks) suffixes
+func parseAge(value string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q; use e.g. 12h, 30d or 8w", value)
+	}
+	return d, nil
+}
+
+func logError(message string, err error) {
+	log.Printf("%s %s: %v", red("✗"), message, err)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Configuration structure to hold command-line flags
+type Config struct {
+	Untracked   bool
+	VerboseMode bool
+}
+
+// Global variables
+var (
+	cfg Config
+	// Color functions for output
+	green  = color.New(color.FgGreen, color.Bold).SprintFunc()
+	red    = color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "stashmanager",
+		Short: "Browse, preview, apply and manage Git stashes",
+		Long:  "Without a subcommand, pick a stash from a list and choose what to do with it.",
+		Args:  cobra.NoArgs,
+		Run:   browse,
+	}
+
+	// Command-line flags
+	rootCmd.PersistentFlags().BoolVarP(&cfg.Untracked, "include-untracked", "u", false, "Include untracked files when previewing or creating stashes")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.VerboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.AddCommand(
+		newListCmd(),
+		newShowCmd(),
+		newApplyCmd(),
+		newDropCmd(),
+		newRenameCmd(),
+		newSaveCmd(),
+		newBranchCmd(),
+		newPruneCmd(),
+	)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatalf("Failed to execute command: %v", err)
+	}
+}
+
+// stash is one entry of the stash list
+type stash struct {
+	Index     int
+	Hash      string
+	Branch    string
+	Message   string
+	Created   time.Time
+	Files     int
+	Additions int
+	Deletions int
+}
+
+// ref returns the stash@{n} name git commands expect
+func (st stash) ref() string {
+	return fmt.Sprintf("stash@{%d}", st.Index)
+}
+
+// summary is the one-line description used in lists and prompts
+func (st stash) summary() string {
+	return fmt.Sprintf("%-10s %-8s %-20s %s  %s",
+		st.ref(), age(st.Created), truncate(st.Branch, 20), st.Message,
+		fmt.Sprintf("(%d file(s), +%d -%d)", st.Files, st.Additions, st.Deletions))
+}
+
+// loadStashes reads every stash with its branch, age and file stats
+func loadStashes() ([]stash, error) {
+	output, err := gitOutput("stash", "list", "--format=%H%x00%ct%x00%gs")
+	if err != nil {
+		return nil, err
+	}
+
+	var stashes []stash
+	for i, line := range nonEmptyLines(output) {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, _ := strconv.ParseInt(fields[1], 10, 64)
+		branch, message := parseSubject(fields[2])
+		st := stash{Index: i, Hash: fields[0], Branch: branch, Message: message, Created: time.Unix(seconds, 0)}
+		st.Files, st.Additions, st.Deletions = stashStats(st)
+		stashes = append(stashes, st)
+	}
+	return stashes, nil
+}
+
+// parseSubject splits "WIP on main: abc123 msg" or "On main: msg" into branch and message
+func parseSubject(subject string) (string, string) {
+	rest := subject
+	for _, prefix := range []string{"WIP on ", "On "} {
+		if strings.HasPrefix(rest, prefix) {
+			rest = strings.TrimPrefix(rest, prefix)
+			branch, message, ok := strings.Cut(rest, ": ")
+			if ok {
+				return branch, message
+			}
+		}
+	}
+	return "-", subject
+}
+
+// stashStats counts changed files and lines, including untracked files
+func stashStats(st stash) (int, int, int) {
+	files, additions, deletions := 0, 0, 0
+	count := func(output string) {
+		for _, line := range nonEmptyLines(output) {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			files++
+			a, _ := strconv.Atoi(fields[0])
+			d, _ := strconv.Atoi(fields[1])
+			additions += a
+			deletions += d
+		}
+	}
+	output, _ := gitOutput("stash", "show", "--numstat", st.ref())
+	count(output)
+	if refExists(st.Hash + "^3") {
+		// Untracked files live in the stash's third parent
+		output, _ = gitOutput("show", "--numstat", "--format=", st.Hash+"^3")
+		count(output)
+	}
+	return files, additions, deletions
+}
+
+// findStash resolves an index argument ("2" or "stash@{2}") to a stash
+func findStash(arg string) stash {
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(arg, "stash@{"), "}"))
+	if err != nil {
+		log.Fatalf(red("Invalid stash %q; use an index from stashmanager list"), arg)
+	}
+	stashes, err := loadStashes()
+	if err != nil {
+		log.Fatalf(red("Failed to list stashes: %v"), err)
+	}
+	if index < 0 || index >= len(stashes) {
+		log.Fatalf(red("No stash with index %d (%d stash(es))"), index, len(stashes))
+	}
+	return stashes[index]
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List stashes with age, branch, message and file stats",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			stashes, err := loadStashes()
+			if err != nil {
+				log.Fatalf(red("Failed to list stashes: %v"), err)
+			}
+			if len(stashes) == 0 {
+				fmt.Println(green("✓ No stashes."))
+				return
+			}
+			for _, st := range stashes {
+				fmt.Println(st.summary())
+			}
+		},
+	}
+}
+
+// browse lets the user pick a stash and an action until they quit
+func browse(cmd *cobra.Command, args []string) {
+	for {
+		stashes, err := loadStashes()
+		if err != nil {
+			log.Fatalf(red("Failed to list stashes: %v"), err)
+		}
+		if len(stashes) == 0 {
+			fmt.Println(green("✓ No stashes."))
+			return
+		}
+
+		options := make([]string, len(stashes))
+		for i, st := range stashes {
+			options[i] = st.summary()
+		}
+		var picked int
+		if err := survey.AskOne(&survey.Select{Message: "Select a stash:", Options: options, PageSize: 15}, &picked); err != nil {
+			return
+		}
+		st := stashes[picked]
+
+		var action string
+		actions := []string{"Preview", "Apply", "Pop", "Rename", "Create branch", "Drop", "Back", "Quit"}
+		if err := survey.AskOne(&survey.Select{Message: fmt.Sprintf("What do you want to do with %s?", st.ref()), Options: actions}, &action); err != nil {
+			return
+		}
+
+		switch action {
+		case "Preview":
+			previewStash(st)
+		case "Apply":
+			applyStash(st, false)
+		case "Pop":
+			applyStash(st, true)
+		case "Rename":
+			message := prompt("New message:", st.Message)
+			renameStash(st, message)
+		case "Create branch":
+			name := prompt("Branch name:", "")
+			stashToBranch(st, name)
+			return
+		case "Drop":
+			if confirm(fmt.Sprintf("Drop %s (%s)?", st.ref(), st.Message)) {
+				dropStash(st)
+			}
+		case "Quit":
+			return
+		}
+	}
+}
+
+func prompt(message, defaultValue string) string {
+	var answer string
+	if err := survey.AskOne(&survey.Input{Message: message, Default: defaultValue}, &answer, survey.WithValidator(survey.Required)); err != nil {
+		log.Fatalf(red("Cancelled: %v"), err)
+	}
+	return strings.TrimSpace(answer)
+}
+
+func confirm(message string) bool {
+	answer := false
+	if err := survey.AskOne(&survey.Confirm{Message: message}, &answer); err != nil {
+		return false
+	}
+	return answer
+}
+
+func age(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
+
+func refExists(ref string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", ref).Run() == nil
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// gitOutput runs a git command and returns its trimmed stdout
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stdout.String()), fmt.Errorf("%s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitPassthrough runs a git command attached to the terminal
+func gitPassthrough(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+func logVerbose(message string) {
+	if cfg.VerboseMode {
+		fmt.Printf("%s %s\n", yellow("→"), message)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/briandowns/spinner v1.23.1 // @latest
 	github.com/fatih/color v1.17.0 // @latest
 	github.com/spf13/cobra v1.8.1 // @latest
+	golang.org/x/term v0.24.0
 )
 
 require (
@@ -14,7 +15,6 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect; indirect // @latest
 	github.com/spf13/pflag v1.0.5 // indirect; indirect // @latest
 	golang.org/x/sys v0.25.0 // indirect; indirect // @latest
-	golang.org/x/term v0.24.0 // indirect; indirect // @latest
 )
 
 require (
@@ -0,0 +1,108 @@
+// Package conventional parses commit messages that follow the Conventional
+// Commits specification, "type(scope)!: description" with optional footers,
+// for the tools that summarize history: commitdiary, changelog and release.
+//
+// Messages that do not follow the specification still parse; their type is
+// Other and their description is the whole subject.
+package conventional
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Other is the type of a commit whose subject is not a Conventional Commit
+const Other = "other"
+
+// TypeOrder lists the common types in the order tools show them
+var TypeOrder = []string{"feat", "fix", "perf", "refactor", "docs", "test", "build", "ci", "style", "chore", "revert"}
+
+var (
+	// subjectPattern matches "type(scope)!: description"
+	subjectPattern = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+	// trailerPattern matches a git trailer, plus the "BREAKING CHANGE" footer
+	// whose key has a space in it
+	trailerPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*|BREAKING CHANGE):\s+(.+)$`)
+	// issuePattern matches GitHub style #12 and tracker keys like PROJ-34
+	issuePattern = regexp.MustCompile(`(?:^|[^\w&/])(#\d+)\b|\b([A-Z][A-Z0-9]+-\d+)\b`)
+)
+
+// Trailer is a "Key: value" line at the end of a commit message
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Message is a parsed commit message
+type Message struct {
+	Type        string    // lower case, Other when the subject does not follow the specification
+	Scope       string    // empty when there is none
+	Breaking    bool      // marked with "!" or a BREAKING CHANGE footer
+	Description string    // subject without the type and scope
+	Trailers    []Trailer // footers of the last paragraph of the body
+	Issues      []string  // issue references in the subject and body, each once
+}
+
+// Parse parses a commit subject and body
+func Parse(subject, body string) Message {
+	m := Message{Trailers: ParseTrailers(body), Issues: FindIssues(subject + "\n" + body)}
+	match := subjectPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if match == nil {
+		m.Type, m.Description = Other, strings.TrimSpace(subject)
+		return m
+	}
+	m.Type = strings.ToLower(match[1])
+	m.Scope = match[2]
+	m.Description = match[4]
+	m.Breaking = match[3] == "!" || len(m.BreakingNotes()) > 0
+	return m
+}
+
+// Trailer returns the values of the trailers with the given key, ignoring case
+func (m Message) Trailer(key string) []string {
+	var values []string
+	for _, t := range m.Trailers {
+		if strings.EqualFold(t.Key, key) {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// BreakingNotes returns the text of the BREAKING CHANGE footers
+func (m Message) BreakingNotes() []string {
+	return append(m.Trailer("BREAKING CHANGE"), m.Trailer("BREAKING-CHANGE")...)
+}
+
+// ParseTrailers reads the last paragraph of a commit body when every line in
+// it is a trailer. Indented lines continue the trailer above them.
+func ParseTrailers(body string) []Trailer {
+	paragraphs := strings.Split(strings.TrimSpace(body), "\n\n")
+	var trailers []Trailer
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if len(trailers) > 0 && strings.TrimSpace(line) != "" && (line[0] == ' ' || line[0] == '\t') {
+			trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		match := trailerPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: match[1], Value: match[2]})
+	}
+	return trailers
+}
+
+// FindIssues returns the issue references in text, each once
+func FindIssues(text string) []string {
+	var issues []string
+	seen := map[string]bool{}
+	for _, match := range issuePattern.FindAllStringSubmatch(text, -1) {
+		issue := match[1] + match[2]
+		if !seen[issue] {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
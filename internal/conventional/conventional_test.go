@@ -0,0 +1,108 @@
+package conventional
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		subject, body string
+		want          Message
+	}{
+		{
+			name:    "type and description",
+			subject: "fix: handle empty input",
+			want:    Message{Type: "fix", Description: "handle empty input"},
+		},
+		{
+			name:    "scope and breaking mark",
+			subject: "feat(api)!: drop the v1 endpoints",
+			want:    Message{Type: "feat", Scope: "api", Breaking: true, Description: "drop the v1 endpoints"},
+		},
+		{
+			name:    "type in upper case",
+			subject: "Docs: explain the flags",
+			want:    Message{Type: "docs", Description: "explain the flags"},
+		},
+		{
+			name:    "not conventional",
+			subject: "  Update readme  ",
+			want:    Message{Type: Other, Description: "Update readme"},
+		},
+		{
+			name:    "breaking change footer",
+			subject: "refactor: rename the config keys",
+			body:    "Keys are shorter now.\n\nBREAKING CHANGE: old keys are ignored",
+			want: Message{Type: "refactor", Breaking: true, Description: "rename the config keys",
+				Trailers: []Trailer{{Key: "BREAKING CHANGE", Value: "old keys are ignored"}}},
+		},
+		{
+			name:    "issues from subject and body, each once",
+			subject: "fix: crash on start (#12)",
+			body:    "Reported in #12 and PROJ-34.",
+			want:    Message{Type: "fix", Description: "crash on start (#12)", Issues: []string{"#12", "PROJ-34"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.subject, tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.subject, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Trailer
+	}{
+		{name: "empty body", body: "", want: nil},
+		{
+			name: "last paragraph of trailers",
+			body: "Some explanation.\n\nReviewed-by: Ann <ann@example.com>\nRefs: #7",
+			want: []Trailer{{Key: "Reviewed-by", Value: "Ann <ann@example.com>"}, {Key: "Refs", Value: "#7"}},
+		},
+		{
+			name: "continuation line",
+			body: "BREAKING CHANGE: the output format\n  changed completely",
+			want: []Trailer{{Key: "BREAKING CHANGE", Value: "the output format changed completely"}},
+		},
+		{
+			name: "paragraph with prose is not trailers",
+			body: "Reviewed-by: Ann\nand some more text",
+			want: nil,
+		},
+		{
+			name: "only the last paragraph counts",
+			body: "Refs: #1\n\nJust a closing remark.",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTrailers(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTrailers(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindIssues(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"fixes #3 and #4, again #3", []string{"#3", "#4"}},
+		{"see PROJ-34", []string{"PROJ-34"}},
+		{"color &#39; and a/#5 are not issues", nil},
+	}
+	for _, tt := range tests {
+		if got := FindIssues(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindIssues(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package journal records the repository state around GitNoob operations so
+// rollbackhelper can undo them.
+//
+// Entries are appended as JSON lines to .git/gitnoob/journal.jsonl. Begin
+// writes the state before an operation and Finish writes the same entry again
+// with the state after it, so an operation that dies halfway still leaves a
+// record of where the repository was.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Undo modes for the branch that was checked out, mirroring git reset
+const (
+	UndoKeep = "keep" // move the branch back, keeping unrelated local changes
+	UndoSoft = "soft" // move the branch back, leaving the undone changes staged
+)
+
+// maxEntries bounds the journal file; older lines are dropped on Begin
+const maxEntries = 500
+
+// State is a snapshot of HEAD and every local branch
+type State struct {
+	Branch string            `json:"branch,omitempty"` // checked-out branch, empty when detached
+	Head   string            `json:"head"`
+	Refs   map[string]string `json:"refs"` // branch name -> commit
+}
+
+// Entry is one recorded operation
+type Entry struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Command     string    `json:"command"`
+	Description string    `json:"description"`
+	UndoMode    string    `json:"undoMode,omitempty"`
+	Before      State     `json:"before"`
+	After       *State    `json:"after,omitempty"`
+	Error       string    `json:"error,omitempty"` // why the operation failed, when it did
+	Undone      bool      `json:"undone,omitempty"`
+}
+
+// Complete reports whether the operation reached Finish
+func (e Entry) Complete() bool {
+	return e.After != nil
+}
+
+// Begin snapshots the repository and records the start of an operation.
+// A nil entry is returned on error; its methods are no-ops so callers can
+// carry on without a journal.
+func Begin(command, description string) (*Entry, error) {
+	state, err := Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		ID:          strconv.FormatInt(time.Now().UnixNano(), 36),
+		Time:        time.Now(),
+		Command:     command,
+		Description: description,
+		Before:      state,
+	}
+	if err := trim(); err != nil {
+		return nil, err
+	}
+	if err := e.write(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Finish records the state after the operation
+func (e *Entry) Finish() error {
+	if e == nil {
+		return nil
+	}
+	state, err := Snapshot()
+	if err != nil {
+		return err
+	}
+	e.After = &state
+	return e.write()
+}
+
+// Fail records the state after an operation that stopped with an error,
+// along with the error
+func (e *Entry) Fail(err error) error {
+	if e == nil {
+		return nil
+	}
+	e.Error = err.Error()
+	return e.Finish()
+}
+
+// MarkUndone records that the operation has been rolled back
+func (e *Entry) MarkUndone() error {
+	if e == nil {
+		return nil
+	}
+	e.Undone = true
+	return e.write()
+}
+
+func (e *Entry) write() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Load returns every recorded operation, newest first. Later lines for the
+// same ID replace earlier ones.
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	byID := map[string]Entry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.ID == "" {
+			// Skip a line cut short by an interrupted write
+			continue
+		}
+		byID[e.ID] = e
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(byID))
+	for _, e := range byID {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	return entries, nil
+}
+
+// trim rewrites the journal keeping only the most recent lines
+func trim() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) <= maxEntries*2 {
+		return nil
+	}
+	kept := strings.Join(lines[len(lines)-maxEntries:], "")
+	return os.WriteFile(path, []byte(kept), 0644)
+}
+
+// Snapshot reads HEAD and all local branches
+func Snapshot() (State, error) {
+	head, err := git("rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		// An unborn branch has no commits to record yet
+		head = ""
+	}
+	branch, _ := git("symbolic-ref", "--quiet", "--short", "HEAD")
+	output, err := git("for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads")
+	if err != nil {
+		return State{}, err
+	}
+
+	refs := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if name, hash, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			refs[name] = hash
+		}
+	}
+	return State{Branch: branch, Head: head, Refs: refs}, nil
+}
+
+// Path returns the journal file of the current repository, shared by all worktrees
+func Path() (string, error) {
+	dir, err := git("rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("not inside a Git repository: %w", err)
+	}
+	return filepath.Join(dir, "gitnoob", "journal.jsonl"), nil
+}
+
+func git(args ...string) (string, error) {
+	output, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(output)), err
+}
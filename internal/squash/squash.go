@@ -0,0 +1,53 @@
+// Package squash recognizes branches that were squash-merged: their changes
+// landed on another branch as one new commit, so ancestry alone does not show
+// them as merged. The check only reads the repository; it never writes
+// objects, so it is safe in dry runs.
+package squash
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Merged reports whether the combined changes of branch, since it left base,
+// landed on base as a single commit. The patch-id of the branch's whole diff
+// is compared with those of the commits on base since the merge base.
+func Merged(branch, base string) bool {
+	mergeBase := strings.TrimSpace(git("merge-base", base, branch))
+	if mergeBase == "" {
+		return false
+	}
+	squashed := patchIDs(git("diff", "--no-color", "--no-ext-diff", mergeBase, branch))
+	if len(squashed) != 1 {
+		// No changes of its own; nothing to compare
+		return false
+	}
+	for _, id := range patchIDs(git("log", "-p", "--no-color", "--no-ext-diff", "--no-merges", mergeBase+".."+base)) {
+		if id == squashed[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// patchIDs returns the stable patch-ids of the patches in diff or log -p output
+func patchIDs(patches string) []string {
+	if patches == "" {
+		return nil
+	}
+	cmd := exec.Command("git", "patch-id", "--stable")
+	cmd.Stdin = strings.NewReader(patches)
+	output, _ := cmd.Output()
+	var ids []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			ids = append(ids, fields[0])
+		}
+	}
+	return ids
+}
+
+func git(args ...string) string {
+	output, _ := exec.Command("git", args...).Output()
+	return string(output)
+}